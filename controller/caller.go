@@ -0,0 +1,272 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sources of the caller identity
+const (
+	callerCertificate string = "certificate"
+	callerHeader      string = "header"
+	callerToken       string = "token"
+	callerAnonymous   string = "anonymous"
+	// The change was made by the settings file, not by a request
+	callerSettings string = "settings"
+)
+
+const (
+	defaultUserHeader  string = "X-Forwarded-User"
+	defaultTokenClaim  string = "preferred_username"
+	maxCallerFieldSize int    = 256
+)
+
+// Context key of the caller of the request
+const callerKey string = "caller"
+
+// The caller of a request is identified, for the audit trail only, in this order:
+//
+//   - the subject of the client certificate, when the request came over TLS with one.
+//   - user_header, only when the request came from one of trusted_proxies (CIDRs or addresses).
+//   - the Authorization header: the user of Basic credentials, token_claim (then sub) of a JWT bearer token,
+//     or a fingerprint of any other token. The token is not verified, the caller is authenticated in front of the exporter.
+//
+// The client IP is the remote address, or the X-Forwarded-For address added by the trusted proxies.
+type yamlAuditConfig struct {
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	UserHeader     string   `yaml:"user_header"`
+	TokenClaim     string   `yaml:"token_claim"`
+
+	// Parsed trusted_proxies, set by validAuditConfig
+	trustedProxies []netip.Prefix
+}
+
+// The audit settings applied, those of the latest sync
+var auditSettings atomic.Pointer[yamlAuditConfig]
+
+// Caller is the identity of the caller of a request, as recorded in the audit trail
+type Caller struct {
+	// User name, or - when the caller is anonymous
+	User string `json:"user"`
+	// Where the user name comes from: certificate, header, token or anonymous
+	Source    string `json:"source"`
+	ClientIp  string `json:"clientIp"`
+	UserAgent string `json:"userAgent"`
+}
+
+// Check the audit_configs settings and fill in the defaults
+func validAuditConfig(settings *yamlAuditConfig) error {
+	settings.trustedProxies = make([]netip.Prefix, 0, len(settings.TrustedProxies))
+	for i, proxy := range settings.TrustedProxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(proxy)
+			if addrErr != nil {
+				return errAuditConfig.Wrap(err, fmt.Sprintf("audit_configs/trusted_proxies[%d]", i), "It must be a CIDR or an IP address.")
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		settings.trustedProxies = append(settings.trustedProxies, prefix.Masked())
+	}
+
+	if settings.UserHeader == "" {
+		settings.UserHeader = defaultUserHeader
+	}
+	if settings.TokenClaim == "" {
+		settings.TokenClaim = defaultTokenClaim
+	}
+
+	return nil
+}
+
+// applyAuditConfig makes the audit settings of a sync apply to the following requests
+func applyAuditConfig(settings *yamlAuditConfig) {
+	auditSettings.Store(settings)
+}
+
+// Return the audit settings that apply, the defaults, with no trusted proxy, before the first sync
+func currentAuditConfig() *yamlAuditConfig {
+	if settings := auditSettings.Load(); settings != nil {
+		return settings
+	}
+	return &yamlAuditConfig{UserHeader: defaultUserHeader, TokenClaim: defaultTokenClaim}
+}
+
+// CallerOf returns the caller of the request, identified on first use
+func CallerOf(c *gin.Context) Caller {
+	if caller, ok := c.Get(callerKey); ok {
+		return caller.(Caller)
+	}
+
+	caller := identifyCaller(c, currentAuditConfig())
+	c.Set(callerKey, caller)
+	return caller
+}
+
+// String returns the caller as recorded in the message of the audit trail
+func (caller Caller) String() string {
+	return fmt.Sprintf("source = %s, clientIp = %s, userAgent = %q", caller.Source, caller.ClientIp, caller.UserAgent)
+}
+
+// Identify the caller of the request
+func identifyCaller(c *gin.Context, settings *yamlAuditConfig) Caller {
+	remoteIp := remoteAddr(c.Request.RemoteAddr)
+	trusted := isTrustedProxy(settings, remoteIp)
+
+	caller := Caller{User: "-", Source: callerAnonymous, ClientIp: remoteIp.String(), UserAgent: sanitizeCallerField(c.Request.UserAgent())}
+	if !remoteIp.IsValid() {
+		caller.ClientIp = sanitizeCallerField(c.Request.RemoteAddr)
+	}
+	if trusted {
+		if ip := forwardedClientIp(settings, c.GetHeader("X-Forwarded-For")); ip != "" {
+			caller.ClientIp = ip
+		}
+	}
+
+	switch {
+	case c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0:
+		subject := c.Request.TLS.PeerCertificates[0].Subject
+		caller.User, caller.Source = subject.CommonName, callerCertificate
+		if caller.User == "" {
+			caller.User = subject.String()
+		}
+	case trusted && c.GetHeader(settings.UserHeader) != "":
+		caller.User, caller.Source = c.GetHeader(settings.UserHeader), callerHeader
+	case c.GetHeader("Authorization") != "":
+		caller.User, caller.Source = tokenUser(c.GetHeader("Authorization"), settings.TokenClaim), callerToken
+	}
+
+	caller.User = sanitizeCallerField(caller.User)
+	if caller.User == "" {
+		caller.User, caller.Source = "-", callerAnonymous
+	}
+	return caller
+}
+
+// Return the IP address of a remote address, invalid when it is not an IP address
+func remoteAddr(address string) netip.Addr {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	addr, _ := netip.ParseAddr(host)
+	return addr.Unmap()
+}
+
+// Report whether the address is one of the trusted proxies
+func isTrustedProxy(settings *yamlAuditConfig, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range settings.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the client address of X-Forwarded-For, the last one that is not a trusted proxy.
+// The addresses before it may have been set by the client itself.
+func forwardedClientIp(settings *yamlAuditConfig, forwardedFor string) string {
+	if forwardedFor == "" {
+		return ""
+	}
+	hops := strings.Split(forwardedFor, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return ""
+		}
+		if !isTrustedProxy(settings, addr.Unmap()) || i == 0 {
+			return addr.Unmap().String()
+		}
+	}
+	return ""
+}
+
+// Return the user of the Authorization header: the user of Basic credentials, the claim of a JWT,
+// or a fingerprint of any other token, so that callers can be told apart without logging their token
+func tokenUser(authorization string, claim string) string {
+	scheme, credentials, _ := strings.Cut(authorization, " ")
+	credentials = strings.TrimSpace(credentials)
+
+	if strings.EqualFold(scheme, "Basic") {
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err == nil {
+			user, _, _ := strings.Cut(string(decoded), ":")
+			return user
+		}
+	}
+
+	if strings.EqualFold(scheme, "Bearer") {
+		if user := jwtClaim(credentials, claim); user != "" {
+			return user
+		}
+	} else {
+		credentials = authorization
+	}
+
+	sum := sha256.Sum256([]byte(credentials))
+	return "token:" + hex.EncodeToString(sum[:4])
+}
+
+// Return the claim, or sub when it is absent, of the payload of a JWT. The signature is not verified.
+func jwtClaim(token string, claim string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	claims := map[string]any{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	for _, name := range []string{claim, "sub"} {
+		if value, ok := claims[name].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Remove the control characters, so that a field cannot forge a line of the trail, and limit its size
+func sanitizeCallerField(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, value)
+	if len(value) > maxCallerFieldSize {
+		value = strings.ToValidUTF8(value[:maxCallerFieldSize], "")
+	}
+	return value
+}
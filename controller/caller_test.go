@@ -0,0 +1,110 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_validAuditConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings yamlAuditConfig
+		wantErr  bool
+	}{
+		{"Normal case: Defaults", yamlAuditConfig{}, false},
+		{"Normal case: CIDR and address", yamlAuditConfig{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1", "::1"}}, false},
+		{"Error case: Not an address", yamlAuditConfig{TrustedProxies: []string{"proxy"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validAuditConfig(&tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validAuditConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (len(tt.settings.trustedProxies) != len(tt.settings.TrustedProxies) || tt.settings.UserHeader == "" || tt.settings.TokenClaim == "") {
+				t.Errorf("validAuditConfig() = %+v", tt.settings)
+			}
+		})
+	}
+}
+
+func Test_identifyCaller(t *testing.T) {
+	settings := yamlAuditConfig{TrustedProxies: []string{"10.0.0.0/8"}}
+	if err := validAuditConfig(&settings); err != nil {
+		t.Fatalf("validAuditConfig() error = %v", err)
+	}
+
+	// {"preferred_username": "alice", "sub": "1234"}
+	jwt := "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(`{"preferred_username":"alice","sub":"1234"}`)) + ".sig"
+	basic := base64.StdEncoding.EncodeToString([]byte("bob:secret"))
+	certificate := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "operator"}}}}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		tls        *tls.ConnectionState
+		want       Caller
+	}{
+		{"Normal case: Anonymous", "192.0.2.1:5000", nil, nil,
+			Caller{"-", callerAnonymous, "192.0.2.1", "test-agent"}},
+		{"Normal case: JWT claim", "192.0.2.1:5000", map[string]string{"Authorization": "Bearer " + jwt}, nil,
+			Caller{"alice", callerToken, "192.0.2.1", "test-agent"}},
+		{"Normal case: Basic credentials", "192.0.2.1:5000", map[string]string{"Authorization": "Basic " + basic}, nil,
+			Caller{"bob", callerToken, "192.0.2.1", "test-agent"}},
+		{"Normal case: Opaque token is fingerprinted", "192.0.2.1:5000", map[string]string{"Authorization": "Bearer opaque"}, nil,
+			Caller{tokenUser("Bearer opaque", defaultTokenClaim), callerToken, "192.0.2.1", "test-agent"}},
+		{"Normal case: Client certificate comes first", "192.0.2.1:5000", map[string]string{"Authorization": "Bearer " + jwt}, certificate,
+			Caller{"operator", callerCertificate, "192.0.2.1", "test-agent"}},
+		{"Normal case: Header of a trusted proxy", "10.0.0.5:5000", map[string]string{"X-Forwarded-User": "carol", "X-Forwarded-For": "198.51.100.7, 10.0.0.9", "Authorization": "Bearer " + jwt}, nil,
+			Caller{"carol", callerHeader, "198.51.100.7", "test-agent"}},
+		{"Error case: Header of an untrusted client is ignored", "192.0.2.1:5000", map[string]string{"X-Forwarded-User": "carol", "X-Forwarded-For": "198.51.100.7"}, nil,
+			Caller{"-", callerAnonymous, "192.0.2.1", "test-agent"}},
+		{"Error case: Control characters are removed", "10.0.0.5:5000", map[string]string{"X-Forwarded-User": "eve\r\nforged"}, nil,
+			Caller{"eveforged", callerHeader, "10.0.0.5", "test-agent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ginContext, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ginContext.Request = httptest.NewRequest(http.MethodPost, "/cdim/api/v1/devices/sync", nil)
+			ginContext.Request.RemoteAddr = tt.remoteAddr
+			ginContext.Request.TLS = tt.tls
+			ginContext.Request.Header.Set("User-Agent", "test-agent")
+			for key, value := range tt.headers {
+				ginContext.Request.Header.Set(key, value)
+			}
+
+			if got := identifyCaller(ginContext, &settings); got != tt.want {
+				t.Errorf("identifyCaller() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_tokenUser_fingerprint(t *testing.T) {
+	first, second := tokenUser("Bearer token-1", defaultTokenClaim), tokenUser("Bearer token-2", defaultTokenClaim)
+	if first == second || first == "Bearer token-1" {
+		t.Errorf("tokenUser() = %s, %s, want distinct fingerprints", first, second)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings supported for the collect response and the forward and alert bodies
+const (
+	encodingGzip     string = "gzip"
+	encodingZstd     string = "zstd"
+	encodingIdentity string = "identity"
+)
+
+var supportedEncodings = []string{encodingGzip, encodingZstd}
+
+// Check that every encoding is supported
+func validConfigEncodings(targetName string, targetValue []string) error {
+	for _, encoding := range targetValue {
+		err := validConfigEncoding(targetName, encoding)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check that the encoding is supported. An empty value means no compression.
+func validConfigEncoding(targetName string, targetValue string) error {
+	if targetValue != "" && !slices.Contains(supportedEncodings, targetValue) {
+		return errEncoding.New(targetName)
+	}
+	return nil
+}
+
+// Return the value of the Accept-Encoding header for the encodings
+func acceptEncodingHeader(encodings []string) string {
+	return strings.Join(encodings, ", ")
+}
+
+// decodeResponseBody returns a reader of the decompressed response body according to its Content-Encoding.
+// Closing the returned reader does not close the response body.
+func decodeResponseBody(resp *http.Response) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "", encodingIdentity:
+		return io.NopCloser(resp.Body), nil
+	case encodingGzip:
+		return gzip.NewReader(resp.Body)
+	case encodingZstd:
+		decoder, err := zstd.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+}
+
+// newEncodingWriter returns a writer compressing into w with the encoding.
+// The returned writer must be closed to flush the compressed data. An empty encoding means no compression.
+func newEncodingWriter(w io.Writer, encoding string) (io.WriteCloser, error) {
+	switch encoding {
+	case "":
+		return nopWriteCloser{w}, nil
+	case encodingGzip:
+		return gzip.NewWriter(w), nil
+	case encodingZstd:
+		return zstd.NewWriter(w)
+	}
+
+	return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+}
+
+// compressBody compresses the whole body with the encoding
+func compressBody(body []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := newEncodingWriter(&buf, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(body); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,110 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_validConfigEncodings(t *testing.T) {
+	tests := []struct {
+		name      string
+		encodings []string
+		wantErr   bool
+	}{
+		{"Normal case: Omitted", nil, false},
+		{"Normal case: gzip and zstd", []string{"gzip", "zstd"}, false},
+		{"Error case: Unsupported encoding", []string{"gzip", "br"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validConfigEncodings("collect_configs/accept_encoding", tt.encodings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validConfigEncodings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_decodeResponseBody(t *testing.T) {
+	plain := []byte(`{"deviceList": []}`)
+
+	tests := []struct {
+		name     string
+		encoding string
+		wantErr  bool
+	}{
+		{"Normal case: Not compressed", "", false},
+		{"Normal case: gzip", encodingGzip, false},
+		{"Normal case: zstd", encodingZstd, false},
+		{"Error case: Unsupported encoding", "br", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := plain
+			if tt.encoding == encodingGzip || tt.encoding == encodingZstd {
+				body, _ = compressBody(plain, tt.encoding)
+			}
+			resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(body))}
+			resp.Header.Set("Content-Encoding", tt.encoding)
+
+			reader, err := decodeResponseBody(resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeResponseBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer reader.Close()
+			got, _ := io.ReadAll(reader)
+			if !bytes.Equal(got, plain) {
+				t.Errorf("decodeResponseBody() = %s, want %s", got, plain)
+			}
+		})
+	}
+}
+
+func Test_forwardData_compressed(t *testing.T) {
+	for _, encoding := range supportedEncodings {
+		t.Run(encoding, func(t *testing.T) {
+			received := make(chan string, 1)
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := &http.Response{Header: r.Header, Body: r.Body}
+				body, err := decodeResponseBody(resp)
+				if err != nil {
+					received <- err.Error()
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				b, _ := io.ReadAll(body)
+				received <- string(b)
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer testServer.Close()
+
+			settings := yamlForwardConfig{TargetUrl: testServer.URL, TimeOut: new(int), ContentEncoding: encoding}
+			forwardData(context.Background(), httpClientFor(&yamlHttpClientConfig{}), &settings, []any{"dev1"})
+
+			if got := <-received; got != "[\"dev1\"\n]" {
+				t.Errorf("forwardData() sent %q", got)
+			}
+		})
+	}
+}
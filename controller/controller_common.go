@@ -19,5 +19,8 @@ import (
 	logger_common "github.com/project-cdim/cdim-go-logger/common"
 )
 
-// Application Logger
-var log, _ = logger.New(logger_common.Option{Tag: logger_common.TAG_APP_EXPORTER /*, LoggingLevel: logger_common.DEBUG*/})
+// Application Logger. It writes every level, the lines are filtered by the runtime level of log (see log_level.go).
+var baseLog, _ = logger.New(logger_common.Option{Tag: logger_common.TAG_APP_EXPORTER, LoggingLevel: logger_common.DEBUG})
+
+// Audit Trail Logger, recording the changes made through the admin API
+var trailLog, _ = logger.New(logger_common.Option{Tag: logger_common.TAG_TRAIL})
@@ -15,16 +15,22 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Error object for Exporter
+// Error object for Exporter. The codes are defined in the error catalog (error_catalog.go).
 type ExpError struct {
 	StatusCode int
 	Code       string
 	Message    string
+	Retryable  bool
+	Category   string
+
+	// Underlying error, reachable with errors.Is and errors.As
+	cause error
 }
 
 // Implementation of the Error method in the error interface
@@ -32,34 +38,56 @@ func (gce *ExpError) Error() string {
 	return fmt.Sprintf("http status code = %d, code = %s message = %s", gce.StatusCode, gce.Code, gce.Message)
 }
 
-// Create a new ExpError
+// Create a new ExpError. The retryability and the category are taken from the error catalog.
 func ExpErrorNew(statusCode int, code string, message string) error {
-	return &ExpError{
+	expErr := &ExpError{
 		StatusCode: statusCode,
 		Code:       code,
 		Message:    message,
 	}
+	if definition, ok := errorCatalog[code]; ok {
+		expErr.Retryable = definition.Retryable
+		expErr.Category = definition.Category
+	}
+	return expErr
 }
 
-// Return the StatusCode value from ExpError
-func GetStatusCode(err error) int {
-	res := 0
-	switch castErr := err.(type) {
+// Return the underlying error
+func (gce *ExpError) Unwrap() error {
+	return gce.cause
+}
+
+// Report whether target is an ExpError or an error definition with the same code
+func (gce *ExpError) Is(target error) bool {
+	switch castTarget := target.(type) {
 	case *ExpError:
-		res = castErr.StatusCode
+		return gce.Code == castTarget.Code
+	case *errorDefinition:
+		return gce.Code == castTarget.Code
+	}
+	return false
+}
+
+// Return the ExpError in the chain of err, or errInternal wrapping err when there is none
+func asExpError(err error) *ExpError {
+	var expErr *ExpError
+	if errors.As(err, &expErr) {
+		return expErr
 	}
-	return res
+	errors.As(errInternal.Wrap(err, err), &expErr)
+	return expErr
+}
+
+// Return the StatusCode value from ExpError
+func GetStatusCode(err error) int {
+	return asExpError(err).StatusCode
 }
 
 // Convert Code and Message of ExpError to gin.H (map format) and return
 func ToJson(err error) gin.H {
-	var res gin.H
-	switch castErr := err.(type) {
-	case *ExpError:
-		res = gin.H{
-			"code":    castErr.Code,
-			"message": castErr.Message,
-		}
+	castErr := asExpError(err)
+	return gin.H{
+		"code":    castErr.Code,
+		"message": castErr.Message,
 	}
-	return res
 }
@@ -0,0 +1,101 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"encoding/json"
+)
+
+// Device is a device of the hw-control bulk information retrieval.
+//
+// The typed fields are extracted from the device object when they have the expected type,
+// and are left empty otherwise. Whether the object is well-formed is checked by validateDevice.
+// Raw holds the whole object, so every field, including the ones not modelled here,
+// is forwarded unchanged.
+type Device struct {
+	ID     string
+	Type   string
+	Status *DeviceStatus
+	Links  []DeviceLink
+	Raw    map[string]any
+}
+
+// DeviceStatus is the status element of a device
+type DeviceStatus struct {
+	State  string
+	Health string
+}
+
+// DeviceLink is an element of the links of a device
+type DeviceLink struct {
+	Type     string
+	DeviceID string
+}
+
+// newDevice creates a Device from a device object
+func newDevice(raw map[string]any) Device {
+	device := Device{Raw: raw}
+
+	device.ID, _ = raw["deviceID"].(string)
+	device.Type, _ = raw["type"].(string)
+
+	if status, ok := raw["status"].(map[string]any); ok {
+		device.Status = &DeviceStatus{}
+		device.Status.State, _ = status["state"].(string)
+		device.Status.Health, _ = status["health"].(string)
+	}
+
+	if links, ok := raw["links"].([]any); ok {
+		for _, link := range links {
+			linkMap, ok := link.(map[string]any)
+			if !ok {
+				continue
+			}
+			deviceLink := DeviceLink{}
+			deviceLink.Type, _ = linkMap["type"].(string)
+			deviceLink.DeviceID, _ = linkMap["deviceID"].(string)
+			device.Links = append(device.Links, deviceLink)
+		}
+	}
+
+	return device
+}
+
+// entryDevice returns the device of an entry of incompleteDeviceList,
+// false when the entry is not a device object with an ID and cannot be told apart
+func entryDevice(entry any) (Device, bool) {
+	raw, ok := entry.(map[string]any)
+	if !ok {
+		return Device{}, false
+	}
+	device := newDevice(raw)
+	return device, device.ID != ""
+}
+
+// UnmarshalJSON decodes a device object, keeping the whole object in Raw
+func (d *Device) UnmarshalJSON(data []byte) error {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*d = newDevice(raw)
+	return nil
+}
+
+// MarshalJSON encodes the device as the object it was decoded from
+func (d Device) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Raw)
+}
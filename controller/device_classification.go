@@ -0,0 +1,192 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reasons of the devices of incompleteDeviceList, the entry of hw-control tells what is missing,
+// and of the devices collected but not classified
+const (
+	incompleteReason  string = "hw-control could not retrieve the whole information of the device."
+	quarantinedReason string = "The device does not conform to the device schema. It was quarantined and not classified."
+	filteredReason    string = "The device is excluded by filter_configs. It was neither forwarded nor classified."
+)
+
+// abnormalDevice is a device with an abnormal status and the evaluation that found it abnormal
+type abnormalDevice struct {
+	Device     Device
+	Evaluation statusEvaluation
+}
+
+// classifiedDevice is a device abnormal or incomplete in the latest full sync
+type classifiedDevice struct {
+	DeviceID string `json:"deviceID,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Reason   string `json:"reason"`
+	// Evaluation of the status of an abnormal device
+	Evaluation *statusEvaluation `json:"evaluation,omitempty"`
+	// ID of the maintenance window suppressing the alert of the device
+	SuppressedBy string `json:"suppressedBy,omitempty"`
+	// Since when the device has been in this state, in consecutive full syncs
+	Since           time.Time `json:"since"`
+	DurationSeconds int64     `json:"durationSeconds"`
+	// Entry of incompleteDeviceList as returned by hw-control
+	Entry any `json:"entry,omitempty"`
+}
+
+// classificationList is the response of the abnormal and incomplete device APIs
+type classificationList struct {
+	InfoTimestamp string             `json:"infoTimestamp"`
+	ClassifiedAt  time.Time          `json:"classifiedAt"`
+	Count         int                `json:"count"`
+	Devices       []classifiedDevice `json:"devices"`
+}
+
+// Classification of the latest full sync. A targeted sync classifies part of the devices only, so it leaves it unchanged.
+var classification struct {
+	sync.RWMutex
+	infoTimestamp string
+	classifiedAt  time.Time
+	abnormal      []classifiedDevice
+	incomplete    []classifiedDevice
+	// Evaluation of the status of every classified device, by ID
+	evaluations map[string]statusEvaluation
+	// Why the devices collected but not classified were not, by ID
+	unclassified map[string]string
+}
+
+// Replace the classification with the abnormal and incomplete devices of a sync and the evaluation of every device.
+// A device that was already in the same state keeps the time it entered it.
+func updateClassification(plan *syncPlan, now time.Time) {
+	suppressedBy := make(map[[2]string]string, len(plan.result.Suppressed))
+	for _, suppressed := range plan.result.Suppressed {
+		suppressedBy[[2]string{suppressed.Alert, suppressed.DeviceID}] = suppressed.WindowID
+	}
+
+	classification.Lock()
+	defer classification.Unlock()
+
+	abnormalSince := sinceByDevice(classification.abnormal)
+	abnormal := make([]classifiedDevice, 0, len(plan.abnormal))
+	for _, device := range plan.abnormal {
+		abnormal = append(abnormal, classifiedDevice{
+			DeviceID:     device.Device.ID,
+			Type:         device.Device.Type,
+			Reason:       device.Evaluation.Message,
+			Evaluation:   &device.Evaluation,
+			SuppressedBy: suppressedBy[[2]string{abnormalStatusDeviceList, device.Device.ID}],
+			Since:        sinceOf(abnormalSince, device.Device.ID, now),
+		})
+	}
+
+	incompleteSince := sinceByDevice(classification.incomplete)
+	incomplete := make([]classifiedDevice, 0, len(plan.incomplete))
+	for _, entry := range plan.incomplete {
+		var id string
+		if entryMap, ok := entry.(map[string]any); ok {
+			id, _ = entryMap["deviceID"].(string)
+		}
+		incomplete = append(incomplete, classifiedDevice{
+			DeviceID:     id,
+			Reason:       incompleteReason,
+			SuppressedBy: suppressedBy[[2]string{incompleteDeviceList, id}],
+			Since:        sinceOf(incompleteSince, id, now),
+			Entry:        entry,
+		})
+	}
+
+	quarantined := make(map[string]bool, len(plan.invalidDevices))
+	for _, device := range plan.invalidDevices {
+		quarantined[device.DeviceID] = true
+	}
+	unclassified := make(map[string]string)
+	for _, device := range plan.collected.Devices {
+		if _, ok := plan.evaluations[device.ID]; ok || device.ID == "" {
+			continue
+		}
+		unclassified[device.ID] = filteredReason
+		if quarantined[device.ID] {
+			unclassified[device.ID] = quarantinedReason
+		}
+	}
+
+	classification.infoTimestamp = plan.timestamp
+	classification.classifiedAt = now.UTC()
+	classification.abnormal = abnormal
+	classification.incomplete = incomplete
+	classification.evaluations = plan.evaluations
+	classification.unclassified = unclassified
+}
+
+// Return the time each device of the list entered its state. Devices without ID cannot be followed.
+func sinceByDevice(devices []classifiedDevice) map[string]time.Time {
+	since := make(map[string]time.Time, len(devices))
+	for _, device := range devices {
+		if device.DeviceID != "" {
+			since[device.DeviceID] = device.Since
+		}
+	}
+	return since
+}
+
+// Return the time the device entered its state, now when it was not in it in the previous sync
+func sinceOf(since map[string]time.Time, id string, now time.Time) time.Time {
+	if t, ok := since[id]; ok && id != "" {
+		return t
+	}
+	return now.UTC()
+}
+
+// Return the abnormal or incomplete list of the classification, with the durations in state at now
+func classificationOf(devices *[]classifiedDevice, now time.Time) classificationList {
+	classification.RLock()
+	defer classification.RUnlock()
+
+	list := classificationList{
+		InfoTimestamp: classification.infoTimestamp,
+		ClassifiedAt:  classification.classifiedAt,
+		Count:         len(*devices),
+		Devices:       make([]classifiedDevice, 0, len(*devices)),
+	}
+	for _, device := range *devices {
+		device.DurationSeconds = int64(now.Sub(device.Since).Seconds())
+		list.Devices = append(list.Devices, device)
+	}
+	return list
+}
+
+// GetAbnormalDevices returns the devices of the latest full sync with an abnormal status,
+// with the reason and since when they have been abnormal.
+//
+// Response Codes:
+//   - 200 OK: Returned with the abnormal devices. It is empty until a sync has collected devices.
+func GetAbnormalDevices(c *gin.Context) {
+	c.JSON(http.StatusOK, classificationOf(&classification.abnormal, time.Now()))
+}
+
+// GetIncompleteDevices returns the devices of the latest full sync in incompleteDeviceList,
+// with the entry of hw-control and since when they have been incomplete.
+//
+// Response Codes:
+//   - 200 OK: Returned with the incomplete devices. It is empty until a sync has collected devices.
+func GetIncompleteDevices(c *gin.Context) {
+	c.JSON(http.StatusOK, classificationOf(&classification.incomplete, time.Now()))
+}
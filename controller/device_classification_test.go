@@ -0,0 +1,124 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Empty the classification after the test
+func resetClassification(t *testing.T) {
+	t.Cleanup(func() { updateClassification(&syncPlan{}, time.Time{}) })
+}
+
+// A plan with the abnormal devices, as pairs of ID and message of the evaluation, and the incomplete entries
+func classifiedPlan(abnormal [][2]string, incomplete ...any) *syncPlan {
+	plan := &syncPlan{timestamp: "2025-01-01T00:00:00Z", collected: Output{IncompleteDevices: incomplete}, incomplete: incomplete}
+	for _, device := range abnormal {
+		plan.abnormal = append(plan.abnormal, abnormalDevice{Device: newDevice(map[string]any{"deviceID": device[0], "type": "CPU"}), Evaluation: statusEvaluation{Rule: ruleNormalValue, Message: device[1]}})
+	}
+	return plan
+}
+
+func Test_updateClassification(t *testing.T) {
+	resetClassification(t)
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(10 * time.Minute)
+
+	updateClassification(classifiedPlan([][2]string{{"cpu1", "state"}, {"cpu2", "health"}},
+		map[string]any{"deviceID": "mem1"}, "no ID"), first)
+	plan := classifiedPlan([][2]string{{"cpu1", "health"}, {"cpu3", "state"}},
+		map[string]any{"deviceID": "mem1"}, "no ID")
+	plan.result.Suppressed = []suppressedDevice{{DeviceID: "cpu3", Alert: abnormalStatusDeviceList, WindowID: "mw-1"}}
+	updateClassification(plan, second)
+
+	abnormal := classificationOf(&classification.abnormal, second.Add(time.Minute))
+	tests := []struct {
+		name         string
+		got          classifiedDevice
+		wantId       string
+		wantReason   string
+		wantSince    time.Time
+		wantDuration int64
+	}{
+		{"Normal case: Still abnormal, with another reason", abnormal.Devices[0], "cpu1", "health", first, 660},
+		{"Normal case: Newly abnormal", abnormal.Devices[1], "cpu3", "state", second, 60},
+	}
+	if abnormal.Count != 2 || len(abnormal.Devices) != 2 {
+		t.Fatalf("classificationOf() = %+v, want 2 abnormal devices", abnormal)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.DeviceID != tt.wantId || tt.got.Reason != tt.wantReason || !tt.got.Since.Equal(tt.wantSince) || tt.got.DurationSeconds != tt.wantDuration {
+				t.Errorf("abnormal device = %+v, want %s, %s since %v for %ds", tt.got, tt.wantId, tt.wantReason, tt.wantSince, tt.wantDuration)
+			}
+		})
+	}
+	if abnormal.Devices[1].SuppressedBy != "mw-1" || abnormal.Devices[0].SuppressedBy != "" {
+		t.Errorf("abnormal devices suppressedBy = %q, %q, want \"\", mw-1", abnormal.Devices[0].SuppressedBy, abnormal.Devices[1].SuppressedBy)
+	}
+
+	// An entry without ID cannot be followed, it is new in every sync
+	incomplete := classificationOf(&classification.incomplete, second)
+	if incomplete.Count != 2 || !incomplete.Devices[0].Since.Equal(first) || !incomplete.Devices[1].Since.Equal(second) {
+		t.Errorf("incomplete devices = %+v", incomplete)
+	}
+	if incomplete.Devices[0].DeviceID != "mem1" || incomplete.Devices[0].Reason != incompleteReason {
+		t.Errorf("incomplete device = %+v", incomplete.Devices[0])
+	}
+}
+
+func TestGetAbnormalDevices(t *testing.T) {
+	resetClassification(t)
+
+	tests := []struct {
+		name      string
+		plan      *syncPlan
+		handler   gin.HandlerFunc
+		wantCount int
+	}{
+		{"Normal case: Before any sync", &syncPlan{}, GetAbnormalDevices, 0},
+		{"Normal case: Abnormal devices", classifiedPlan([][2]string{{"cpu1", "state"}}), GetAbnormalDevices, 1},
+		{"Normal case: Incomplete devices", classifiedPlan(nil, map[string]any{"deviceID": "mem1"}, map[string]any{"deviceID": "mem2"}), GetIncompleteDevices, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateClassification(tt.plan, time.Now())
+
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodGet, "/cdim/api/v1/devices/abnormal", nil)
+
+			tt.handler(ginContext)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			var got classificationList
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body is not JSON: %v", err)
+			}
+			if got.Count != tt.wantCount || len(got.Devices) != tt.wantCount || got.InfoTimestamp != tt.plan.timestamp {
+				t.Errorf("body = %s, want %d devices", w.Body.String(), tt.wantCount)
+			}
+		})
+	}
+}
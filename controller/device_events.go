@@ -0,0 +1,291 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Types of the device events
+const (
+	deviceAdded         string = "added"
+	deviceRemoved       string = "removed"
+	deviceStateChanged  string = "stateChanged"
+	deviceHealthChanged string = "healthChanged"
+)
+
+const (
+	defaultMaxEvents int = 1000
+	maxEventsLimit   int = 100000
+	// Interval of the comments keeping an idle event stream open through proxies
+	eventKeepAlive time.Duration = 30 * time.Second
+)
+
+// The devices of consecutive full syncs are compared, and the changes are kept as events in a bounded log
+// and streamed by /devices/events. The first sync after a start is the baseline, it has no event.
+//
+//   - max_events: number of events kept, the oldest are dropped.
+type yamlEventConfig struct {
+	MaxEvents *int `yaml:"max_events"`
+}
+
+// deviceEvent is a change of a device between two full syncs
+type deviceEvent struct {
+	// Sequence number of the event, increasing from 1 since the start
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	DeviceID   string    `json:"deviceID"`
+	DeviceType string    `json:"deviceType,omitempty"`
+	At         time.Time `json:"at"`
+	// infoTimestamp of the snapshot the change was found in
+	InfoTimestamp string `json:"infoTimestamp"`
+	// State or health before and after the change
+	Previous string `json:"previous,omitempty"`
+	Current  string `json:"current,omitempty"`
+}
+
+// The event log, oldest event first. The streams read it from the ID of the last event they have sent,
+// so that a stream never drops an event still in the log, however many events a sync publishes.
+var deviceEvents struct {
+	sync.Mutex
+	lastId int64
+	log    []deviceEvent
+	// Closed and replaced whenever events are published or the streams are closed, to wake the streams up
+	published chan struct{}
+	// Set on shutdown, the streams end
+	closed bool
+}
+
+// Check the event_configs settings and fill in the defaults
+func validEventConfig(settings *yamlEventConfig) error {
+	var err error
+	settings.MaxEvents, err = validConfigCount("event_configs/max_events", settings.MaxEvents, defaultMaxEvents, maxEventsLimit)
+	return err
+}
+
+// diffDevices returns the changes from the devices of the previous snapshot to the current ones.
+// Devices without ID cannot be followed and are left out.
+func diffDevices(previous []Device, current []Device) []deviceEvent {
+	before := make(map[string]*Device, len(previous))
+	for i := range previous {
+		if previous[i].ID != "" {
+			before[previous[i].ID] = &previous[i]
+		}
+	}
+
+	events := make([]deviceEvent, 0)
+	seen := make(map[string]bool, len(current))
+	for i := range current {
+		device := &current[i]
+		if device.ID == "" || seen[device.ID] {
+			continue
+		}
+		seen[device.ID] = true
+
+		old, ok := before[device.ID]
+		if !ok {
+			events = append(events, deviceEvent{Type: deviceAdded, DeviceID: device.ID, DeviceType: device.Type})
+			continue
+		}
+		oldState, oldHealth := statusValues(old)
+		state, health := statusValues(device)
+		if oldState != state {
+			events = append(events, deviceEvent{Type: deviceStateChanged, DeviceID: device.ID, DeviceType: device.Type, Previous: oldState, Current: state})
+		}
+		if oldHealth != health {
+			events = append(events, deviceEvent{Type: deviceHealthChanged, DeviceID: device.ID, DeviceType: device.Type, Previous: oldHealth, Current: health})
+		}
+	}
+
+	for i := range previous {
+		if id := previous[i].ID; id != "" && !seen[id] {
+			seen[id] = true
+			events = append(events, deviceEvent{Type: deviceRemoved, DeviceID: id, DeviceType: previous[i].Type})
+		}
+	}
+
+	return events
+}
+
+// Return the state and health of the device, empty when it has no status
+func statusValues(device *Device) (string, string) {
+	if device.Status == nil {
+		return "", ""
+	}
+	return device.Status.State, device.Status.Health
+}
+
+// publishDeviceEvents numbers the events, adds them to the log of at most maxEvents events and wakes the streams up
+func publishDeviceEvents(ctx context.Context, events []deviceEvent, infoTimestamp string, maxEvents int) {
+	if len(events) == 0 {
+		return
+	}
+
+	deviceEvents.Lock()
+	defer deviceEvents.Unlock()
+
+	now := time.Now().UTC()
+	for i := range events {
+		deviceEvents.lastId++
+		events[i].ID = deviceEvents.lastId
+		events[i].At = now
+		events[i].InfoTimestamp = infoTimestamp
+		logFor(ctx).Info(fmt.Sprintf("device %s %s. %s -> %s", events[i].DeviceID, events[i].Type, events[i].Previous, events[i].Current))
+	}
+
+	deviceEvents.log = append(deviceEvents.log, events...)
+	if len(deviceEvents.log) > maxEvents {
+		deviceEvents.log = append([]deviceEvent(nil), deviceEvents.log[len(deviceEvents.log)-maxEvents:]...)
+	}
+	wakeEventStreams()
+}
+
+// Wake the streams up. The caller holds the lock.
+func wakeEventStreams() {
+	if deviceEvents.published != nil {
+		close(deviceEvents.published)
+	}
+	deviceEvents.published = make(chan struct{})
+}
+
+// resumeEventsFrom returns the ID the stream of a client starts after.
+// Without last event ID, or with one of before a restart, the stream starts after the latest event.
+func resumeEventsFrom(lastId int64) int64 {
+	deviceEvents.Lock()
+	defer deviceEvents.Unlock()
+
+	if lastId < 0 || lastId > deviceEvents.lastId {
+		return deviceEvents.lastId
+	}
+	return lastId
+}
+
+// eventsAfter returns the events of the log after lastId, a channel closed when there are new ones,
+// and whether the streams are closed
+func eventsAfter(lastId int64) ([]deviceEvent, <-chan struct{}, bool) {
+	deviceEvents.Lock()
+	defer deviceEvents.Unlock()
+
+	if deviceEvents.published == nil {
+		deviceEvents.published = make(chan struct{})
+	}
+	start := len(deviceEvents.log)
+	for start > 0 && deviceEvents.log[start-1].ID > lastId {
+		start--
+	}
+	return slices.Clone(deviceEvents.log[start:]), deviceEvents.published, deviceEvents.closed
+}
+
+// CloseEventStreams ends the event streams, which would otherwise keep the server from shutting down.
+func CloseEventStreams() {
+	deviceEvents.Lock()
+	defer deviceEvents.Unlock()
+
+	deviceEvents.closed = true
+	wakeEventStreams()
+}
+
+// Write the event in the Server-Sent Events format
+func writeDeviceEvent(w gin.ResponseWriter, event deviceEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	w.Flush()
+	return err
+}
+
+// GetDeviceEvents streams the changes of the devices between consecutive full syncs as Server-Sent Events.
+// Each event has the sequence number as id, the type as event (added, removed, stateChanged or healthChanged),
+// and the change as JSON data.
+//
+// The events of the log after the Last-Event-ID header, or the lastEventId query parameter, are sent first,
+// so that a client reconnecting misses no event still in the log. Without them, only the following events are sent.
+//
+// The ids are consecutive. The log keeps the latest event_configs/max_events events only, so a client that falls
+// further behind, or reconnects after a longer time, misses the oldest ones: the id it receives next is then
+// more than its last id plus one. The ids start over from 1 after a restart, which a client detects by an id
+// lower than its last one.
+//
+// Response Codes:
+//   - 200 OK: Returned with the event stream, which is open until the client closes it.
+//   - 400 Bad Request: Returned when the last event ID is not a non-negative integer.
+func GetDeviceEvents(c *gin.Context) {
+	logger := logFor(c.Request.Context())
+
+	lastId := int64(-1)
+	rawLastId := c.GetHeader("Last-Event-ID")
+	if rawLastId == "" {
+		rawLastId = c.Query("lastEventId")
+	}
+	if rawLastId != "" {
+		id, err := strconv.ParseInt(rawLastId, 10, 64)
+		if err != nil || id < 0 {
+			err := errEventQuery.New(rawLastId)
+			logger.Error(err.Error())
+			writeProblem(c, err)
+			return
+		}
+		lastId = id
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	sent := resumeEventsFrom(lastId)
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		events, published, closed := eventsAfter(sent)
+		if len(events) > 0 && events[0].ID > sent+1 {
+			logger.Warn(fmt.Sprintf("The events %d to %d are no longer in the log and are not streamed.", sent+1, events[0].ID-1))
+		}
+		for _, event := range events {
+			if err := writeDeviceEvent(c.Writer, event); err != nil {
+				return
+			}
+			sent = event.ID
+		}
+		if closed {
+			logger.Info("The event stream is closed, the server is shutting down. The client can resume it with Last-Event-ID.")
+			return
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-published:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Empty the event log and end the streams after the test
+func resetDeviceEvents(t *testing.T) {
+	reset := func() {
+		CloseEventStreams()
+		deviceEvents.Lock()
+		deviceEvents.lastId, deviceEvents.log, deviceEvents.published, deviceEvents.closed = 0, nil, nil, false
+		deviceEvents.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// Decode the devices of a JSON array
+func devicesOf(t *testing.T, devices string) []Device {
+	var got []Device
+	if err := json.Unmarshal([]byte(devices), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	return got
+}
+
+// Describe the events as "type deviceID previous->current"
+func eventsOf(events []deviceEvent) string {
+	described := make([]string, 0, len(events))
+	for _, event := range events {
+		described = append(described, fmt.Sprintf("%s %s %s->%s", event.Type, event.DeviceID, event.Previous, event.Current))
+	}
+	return strings.Join(described, ", ")
+}
+
+func Test_diffDevices(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		want     string
+	}{
+		{
+			"Normal case: No change",
+			`[{"deviceID": "cpu1", "status": {"state": "Enabled", "health": "OK"}}]`,
+			`[{"deviceID": "cpu1", "status": {"state": "Enabled", "health": "OK"}}]`,
+			"",
+		},
+		{
+			"Normal case: Added and removed",
+			`[{"deviceID": "cpu1"}, {"deviceID": "cpu2"}]`,
+			`[{"deviceID": "cpu2"}, {"deviceID": "cpu3"}]`,
+			"added cpu3 ->, removed cpu1 ->",
+		},
+		{
+			"Normal case: State and health changed",
+			`[{"deviceID": "cpu1", "status": {"state": "Enabled", "health": "OK"}}]`,
+			`[{"deviceID": "cpu1", "status": {"state": "Disabled", "health": "Critical"}}]`,
+			"stateChanged cpu1 Enabled->Disabled, healthChanged cpu1 OK->Critical",
+		},
+		{
+			"Normal case: Status lost",
+			`[{"deviceID": "cpu1", "status": {"state": "Enabled", "health": "OK"}}]`,
+			`[{"deviceID": "cpu1"}]`,
+			"stateChanged cpu1 Enabled->, healthChanged cpu1 OK->",
+		},
+		{
+			"Normal case: Devices without ID and duplicates are left out",
+			`[{"type": "CPU"}, {"deviceID": "cpu1"}]`,
+			`[{"type": "memory"}, {"deviceID": "cpu1"}, {"deviceID": "cpu1", "status": {"state": "Enabled"}}, {"deviceID": "mem1"}, {"deviceID": "mem1"}]`,
+			"added mem1 ->",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventsOf(diffDevices(devicesOf(t, tt.previous), devicesOf(t, tt.current))); got != tt.want {
+				t.Errorf("diffDevices() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_publishDeviceEvents(t *testing.T) {
+	resetDeviceEvents(t)
+
+	_, published, _ := eventsAfter(0)
+	for i := range 5 {
+		publishDeviceEvents(context.Background(), []deviceEvent{{Type: deviceAdded, DeviceID: fmt.Sprintf("dev%d", i)}}, "2025-01-01T00:00:00Z", 3)
+	}
+	select {
+	case <-published:
+	default:
+		t.Errorf("publishDeviceEvents() did not wake the streams up")
+	}
+
+	// The log keeps the latest events only
+	events, _, _ := eventsAfter(0)
+	if len(events) != 3 || events[0].ID != 3 || events[2].ID != 5 || events[2].DeviceID != "dev4" || events[2].InfoTimestamp != "2025-01-01T00:00:00Z" {
+		t.Errorf("event log = %+v, want the events 3 to 5", events)
+	}
+	if events, _, _ := eventsAfter(4); len(events) != 1 || events[0].ID != 5 {
+		t.Errorf("event log after 4 = %+v, want the event 5", events)
+	}
+
+	tests := []struct {
+		name   string
+		lastId int64
+		want   int64
+	}{
+		{"Normal case: Last event ID in the log", 4, 4},
+		{"Normal case: Last event ID dropped from the log", 1, 1},
+		{"Normal case: No last event ID", -1, 5},
+		{"Normal case: Last event ID of before a restart", 9, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resumeEventsFrom(tt.lastId); got != tt.want {
+				t.Errorf("resumeEventsFrom() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// Open the event stream of a test server and return a reader of its lines
+func openEventStream(t *testing.T, url string, lastEventId string) (*http.Response, *bufio.Reader) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("http.Do() error = %v", err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	return res, bufio.NewReader(res.Body)
+}
+
+// Read the next event of the stream as its id, event and data lines
+func readEvent(t *testing.T, reader *bufio.Reader) string {
+	lines := make([]string, 0, 3)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v, read %q", err, lines)
+		}
+		if line == "\n" {
+			return strings.Join(lines, "|")
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func TestGetDeviceEvents(t *testing.T) {
+	resetDeviceEvents(t)
+	router := gin.New()
+	router.GET("/devices/events", GetDeviceEvents)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	publishDeviceEvents(context.Background(), []deviceEvent{
+		{Type: deviceAdded, DeviceID: "cpu1"},
+		{Type: deviceStateChanged, DeviceID: "cpu2", Previous: "Enabled", Current: "Disabled"},
+	}, "", defaultMaxEvents)
+
+	// A client resuming after the event 1 receives the event 2 of the log, then the following events
+	res, reader := openEventStream(t, server.URL+"/devices/events", "1")
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GetDeviceEvents() status = %d, content type = %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	if got := readEvent(t, reader); !strings.HasPrefix(got, "id: 2|event: stateChanged|data: ") || !strings.Contains(got, `"previous":"Enabled"`) {
+		t.Errorf("first event = %q, want the event 2", got)
+	}
+
+	publishDeviceEvents(context.Background(), []deviceEvent{{Type: deviceRemoved, DeviceID: "cpu1"}}, "", defaultMaxEvents)
+	if got := readEvent(t, reader); !strings.HasPrefix(got, "id: 3|event: removed|data: ") {
+		t.Errorf("next event = %q, want the event 3", got)
+	}
+
+	// A sync changing many devices at once is streamed whole
+	burst := make([]deviceEvent, 1000)
+	for i := range burst {
+		burst[i] = deviceEvent{Type: deviceAdded, DeviceID: fmt.Sprintf("dev%d", i)}
+	}
+	publishDeviceEvents(context.Background(), burst, "", defaultMaxEvents)
+	for want := 4; want < 4+len(burst); want++ {
+		if got := readEvent(t, reader); !strings.HasPrefix(got, fmt.Sprintf("id: %d|", want)) {
+			t.Fatalf("event = %q, want the event %d", got, want)
+		}
+	}
+
+	// The stream ends on shutdown
+	CloseEventStreams()
+	if _, err := reader.ReadString('\n'); err == nil {
+		t.Errorf("the stream is still open after CloseEventStreams()")
+	}
+}
+
+func TestGetDeviceEvents_lastEventId(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastEventId string
+	}{
+		{"Error case: Not a number", "abc"},
+		{"Error case: Negative", "-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodGet, "/cdim/api/v1/devices/events?lastEventId="+tt.lastEventId, nil)
+
+			done := make(chan struct{})
+			go func() {
+				GetDeviceEvents(ginContext)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("GetDeviceEvents() did not return")
+			}
+
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"0037"`) {
+				t.Errorf("GetDeviceEvents() status = %d, body = %s, want 400 with code 0037", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+)
+
+// Filters applied to the collected devices before the quarantine, the forward and the status classification.
+// When include is not empty, only the devices matching one of its selectors are kept.
+// The devices matching one of the selectors of exclude are then removed.
+// Filtered devices are neither forwarded nor alerted on. The entries of incompleteDeviceList are filtered as well,
+// those without a deviceID cannot be matched and are always kept.
+type yamlFilterConfig struct {
+	Include []yamlDeviceSelector `yaml:"include"`
+	Exclude []yamlDeviceSelector `yaml:"exclude"`
+
+	// Compiled selectors, set by validFilterConfig
+	include []*deviceSelector
+	exclude []*deviceSelector
+}
+
+// Check the filter_configs settings and compile the selectors
+func validFilterConfig(settings *yamlFilterConfig, labels map[string][]string) error {
+	var err error
+
+	settings.include, err = compileDeviceSelectors("filter_configs/include", settings.Include, labels)
+	if err != nil {
+		return err
+	}
+
+	settings.exclude, err = compileDeviceSelectors("filter_configs/exclude", settings.Exclude, labels)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// filterDevices returns the devices kept by the filters and the number of devices filtered out
+func filterDevices(ctx context.Context, settings *yamlFilterConfig, devices []Device) ([]Device, int) {
+	if len(settings.include) == 0 && len(settings.exclude) == 0 {
+		return devices, 0
+	}
+
+	kept := make([]Device, 0, len(devices))
+	for i := range devices {
+		if !settings.filtersOut(ctx, &devices[i], deviceLabel(&devices[i], i)) {
+			kept = append(kept, devices[i])
+		}
+	}
+
+	filtered := len(devices) - len(kept)
+	if filtered > 0 {
+		logFor(ctx).Info(fmt.Sprintf("%d of %d devices were filtered out and are neither forwarded nor alerted on.", filtered, len(devices)))
+	}
+
+	return kept, filtered
+}
+
+// filterIncompleteEntries returns the entries of incompleteDeviceList kept by the filters
+// and the number of entries filtered out. Entries without a deviceID are kept.
+func filterIncompleteEntries(ctx context.Context, settings *yamlFilterConfig, entries []any) ([]any, int) {
+	if len(settings.include) == 0 && len(settings.exclude) == 0 {
+		return entries, 0
+	}
+
+	kept := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		device, ok := entryDevice(entry)
+		if !ok || !settings.filtersOut(ctx, &device, device.ID) {
+			kept = append(kept, entry)
+		}
+	}
+
+	filtered := len(entries) - len(kept)
+	if filtered > 0 {
+		logFor(ctx).Info(fmt.Sprintf("%d of %d %s entries were filtered out and are not alerted on.", filtered, len(entries), incompleteDeviceList))
+	}
+
+	return kept, filtered
+}
+
+// Report whether the filters remove the device, label identifies it in the log
+func (s *yamlFilterConfig) filtersOut(ctx context.Context, device *Device, label string) bool {
+	if len(s.include) > 0 && matchAny(s.include, device) < 0 {
+		logFor(ctx).Debug(fmt.Sprintf("device %s is filtered out. It matches no selector of filter_configs/include.", label))
+		return true
+	}
+	if selector := matchAny(s.exclude, device); selector >= 0 {
+		logFor(ctx).Debug(fmt.Sprintf("device %s is filtered out by filter_configs/exclude[%d].", label, selector))
+		return true
+	}
+	return false
+}
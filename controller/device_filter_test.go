@@ -0,0 +1,125 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func Test_filterDevices(t *testing.T) {
+	devices := []Device{
+		newDevice(map[string]any{"deviceID": "dev1", "type": "CPU"}),
+		newDevice(map[string]any{"deviceID": "dev2", "type": "memory"}),
+		newDevice(map[string]any{"deviceID": "lab1", "type": "CPU"}),
+	}
+	labels := map[string][]string{"lab": {"lab1"}}
+
+	tests := []struct {
+		name         string
+		settings     yamlFilterConfig
+		wantIDs      []string
+		wantFiltered int
+	}{
+		{
+			"Normal case: No filter",
+			yamlFilterConfig{},
+			[]string{"dev1", "dev2", "lab1"},
+			0,
+		},
+		{
+			"Normal case: Include by type",
+			yamlFilterConfig{Include: []yamlDeviceSelector{{Types: []string{"CPU"}}}},
+			[]string{"dev1", "lab1"},
+			1,
+		},
+		{
+			"Normal case: Exclude by label",
+			yamlFilterConfig{Exclude: []yamlDeviceSelector{{Labels: []string{"lab"}}}},
+			[]string{"dev1", "dev2"},
+			1,
+		},
+		{
+			"Normal case: Exclude applies after include",
+			yamlFilterConfig{
+				Include: []yamlDeviceSelector{{Types: []string{"CPU"}}},
+				Exclude: []yamlDeviceSelector{{Fields: []yamlFieldMatch{{Path: "$.deviceID", Pattern: "^lab"}}}},
+			},
+			[]string{"dev1"},
+			2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validFilterConfig(&tt.settings, labels); err != nil {
+				t.Fatalf("validFilterConfig() error = %v", err)
+			}
+			kept, filtered := filterDevices(context.Background(), &tt.settings, devices)
+			ids := make([]string, 0, len(kept))
+			for _, device := range kept {
+				ids = append(ids, device.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) || filtered != tt.wantFiltered {
+				t.Errorf("filterDevices() = %v, %d, want %v, %d", ids, filtered, tt.wantIDs, tt.wantFiltered)
+			}
+		})
+	}
+}
+
+func Test_planSync_filtersBeforeQuarantine(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"deviceList": [
+			{"deviceID": "dev1", "type": "CPU", "status": {"state": "Enabled", "health": "OK"}},
+			{"deviceID": "lab1", "status": {"state": "Enabled", "health": "OK"}}
+		], "incompleteDeviceList": [{"deviceID": "lab1"}, {"deviceID": "dev2"}, "unknown"],
+		"infoTimestamp": "2025-01-01T00:00:00Z"}`))
+	}))
+	defer testServer.Close()
+
+	settings := yamlContent{}
+	if err := loadConfig("testdata/exporter.yaml", &settings); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	settings.CollectConfigs.TargetUrl = testServer.URL
+	settings.ValidationConfigs.Alert = true
+	settings.FilterConfigs = yamlFilterConfig{Exclude: []yamlDeviceSelector{{DeviceIDs: []string{"lab1"}}}}
+	if err := validFilterConfig(&settings.FilterConfigs, nil); err != nil {
+		t.Fatalf("validFilterConfig() error = %v", err)
+	}
+
+	plan, err := planSync(context.Background(), &settings, nil)
+	if err != nil {
+		t.Fatalf("planSync() error = %v", err)
+	}
+
+	// lab1 does not conform to the schema, but it is excluded before the quarantine
+	if len(plan.invalidDevices) != 0 || plan.result.FilteredDevices != 2 || plan.result.ForwardedDevices != 1 {
+		t.Errorf("planSync() quarantined = %+v, result = %+v, want lab1 filtered out only", plan.invalidDevices, plan.result)
+	}
+	for _, alert := range plan.alerts {
+		switch alert.name {
+		case invalidDeviceList:
+			t.Errorf("planSync() alerts on %s: %v", alert.name, alert.entries)
+		case incompleteDeviceList:
+			if len(alert.entries) != 2 || chatDetails(alert) != "dev2, entries[1]" {
+				t.Errorf("planSync() %s = %v, want dev2 and the entry without ID", alert.name, alert.entries)
+			}
+		}
+	}
+}
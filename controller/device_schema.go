@@ -0,0 +1,170 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// JSON Schema of a device of the hw-control response.
+// It requires the ID and the type only, a missing status is found by the status classification.
+//
+//go:embed schemas/device.json
+var deviceSchemaJson []byte
+
+// Location the embedded schema is registered at
+const deviceSchemaUrl string = "device.json"
+
+// Printer for the messages of the validation errors
+var schemaMessagePrinter = message.NewPrinter(language.English)
+
+// The embedded device schema, compiled on first use
+var compileDeviceSchema = sync.OnceValues(func() (*jsonschema.Schema, error) {
+	return compileSchema(deviceSchemaUrl, deviceSchemaJson)
+})
+
+// The device schema loaded from validation_configs/schema_file, and the content it was compiled from.
+// It is compiled again only when the content of the file changes.
+var fileSchema struct {
+	sync.Mutex
+	path    string
+	content []byte
+	schema  *jsonschema.Schema
+}
+
+type yamlValidationConfig struct {
+	SchemaFile string `yaml:"schema_file"`
+	Alert      bool   `yaml:"alert"`
+}
+
+// deviceIssue is a reason why a device does not conform to the schema
+type deviceIssue struct {
+	// JSON Pointer to the offending value within the device
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// String returns the issue in the form "<path>: <message>"
+func (i deviceIssue) String() string {
+	return i.Path + ": " + i.Message
+}
+
+// loadDeviceSchema returns the device schema in effect.
+// It is the schema in validation_configs/schema_file when set, or the embedded schema otherwise.
+func loadDeviceSchema(settings *yamlValidationConfig) (*jsonschema.Schema, error) {
+	if settings.SchemaFile == "" {
+		schema, err := compileDeviceSchema()
+		if err != nil {
+			return nil, errDeviceSchema.Wrap(err, err)
+		}
+		return schema, nil
+	}
+
+	content, err := os.ReadFile(settings.SchemaFile)
+	if err != nil {
+		return nil, errReadFile.Wrap(err)
+	}
+
+	fileSchema.Lock()
+	defer fileSchema.Unlock()
+
+	if fileSchema.schema != nil && fileSchema.path == settings.SchemaFile && bytes.Equal(fileSchema.content, content) {
+		return fileSchema.schema, nil
+	}
+
+	schema, err := compileSchema(settings.SchemaFile, content)
+	if err != nil {
+		return nil, errDeviceSchema.Wrap(err, err)
+	}
+	fileSchema.path = settings.SchemaFile
+	fileSchema.content = content
+	fileSchema.schema = schema
+
+	return schema, nil
+}
+
+// Compile a JSON Schema document
+func compileSchema(url string, schemaJson []byte) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaJson))
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err = compiler.AddResource(url, doc); err != nil {
+		return nil, err
+	}
+
+	return compiler.Compile(url)
+}
+
+// validateDevice validates the device against the schema.
+// It returns one issue for each value that does not conform, or nil when the device is valid.
+func validateDevice(schema *jsonschema.Schema, device *Device) []deviceIssue {
+	err := schema.Validate(device.Raw)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return []deviceIssue{{Path: "", Message: err.Error()}}
+	}
+
+	return collectIssues(validationErr, nil)
+}
+
+// Collect the innermost causes of a validation error, which point at the offending values
+func collectIssues(err *jsonschema.ValidationError, issues []deviceIssue) []deviceIssue {
+	if len(err.Causes) == 0 {
+		return append(issues, deviceIssue{
+			Path:    jsonPointer(err.InstanceLocation),
+			Message: err.ErrorKind.LocalizedString(schemaMessagePrinter),
+		})
+	}
+
+	for _, cause := range err.Causes {
+		issues = collectIssues(cause, issues)
+	}
+	return issues
+}
+
+// Build a JSON Pointer from its reference tokens
+func jsonPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteString("/")
+		sb.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return sb.String()
+}
+
+// Return a label identifying the device in messages, its ID or its position when it has none
+func deviceLabel(device *Device, index int) string {
+	if device.ID != "" {
+		return device.ID
+	}
+	return fmt.Sprintf("deviceList[%d]", index)
+}
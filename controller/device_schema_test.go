@@ -0,0 +1,87 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"testing"
+)
+
+func Test_validateDevice(t *testing.T) {
+	schema, err := compileDeviceSchema()
+	if err != nil {
+		t.Fatalf("compileDeviceSchema() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		raw       map[string]any
+		wantPaths []string
+	}{
+		{
+			"Normal case: Valid device",
+			map[string]any{"deviceID": "dev1", "type": "CPU", "status": map[string]any{"state": "Enabled", "health": "OK"}},
+			nil,
+		},
+		{
+			"Error case: deviceID is missing",
+			map[string]any{"type": "CPU", "status": map[string]any{"state": "Enabled", "health": "OK"}},
+			[]string{""},
+		},
+		{
+			"Error case: type is missing",
+			map[string]any{"deviceID": "dev1", "status": map[string]any{"state": "Enabled", "health": "OK"}},
+			[]string{""},
+		},
+		{
+			"Normal case: status is missing, it is left to the status classification",
+			map[string]any{"deviceID": "dev1", "type": "CPU"},
+			nil,
+		},
+		{
+			"Normal case: status.health is missing and status.state is not a string",
+			map[string]any{"deviceID": "dev1", "type": "CPU", "status": map[string]any{"state": 1}},
+			nil,
+		},
+		{
+			"Error case: Several values do not conform",
+			map[string]any{"deviceID": "", "type": "CPU", "status": map[string]any{"state": "Enabled", "health": "OK"}, "links": []any{"dev2"}},
+			[]string{"/deviceID", "/links/0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := newDevice(tt.raw)
+			issues := validateDevice(schema, &device)
+			if len(issues) != len(tt.wantPaths) {
+				t.Fatalf("validateDevice() = %v, want issues at %v", issues, tt.wantPaths)
+			}
+			for _, issue := range issues {
+				found := false
+				for _, path := range tt.wantPaths {
+					found = found || issue.Path == path
+				}
+				if !found || issue.Message == "" {
+					t.Errorf("validateDevice() unexpected issue %v, want issues at %v", issue, tt.wantPaths)
+				}
+			}
+		})
+	}
+}
+
+func Test_jsonPointer(t *testing.T) {
+	if got := jsonPointer([]string{"a/b", "c~d", "0"}); got != "/a~1b/c~0d/0" {
+		t.Errorf("jsonPointer() = %v, want %v", got, "/a~1b/c~0d/0")
+	}
+}
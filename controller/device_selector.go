@@ -0,0 +1,149 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// A device selector matches a device when every criterion it specifies matches:
+//
+//   - device_ids: the device ID is one of them.
+//   - types:      the device type is one of them.
+//   - labels:     the device ID is listed under one of the labels in device_labels.
+//   - fields:     for every entry, a value selected by the JSONPath path matches the regular expression pattern.
+//
+// Selectors are also accepted in API request bodies, with the JSON names.
+type yamlDeviceSelector struct {
+	DeviceIDs []string         `yaml:"device_ids" json:"deviceIDs,omitempty"`
+	Types     []string         `yaml:"types" json:"types,omitempty"`
+	Labels    []string         `yaml:"labels" json:"labels,omitempty"`
+	Fields    []yamlFieldMatch `yaml:"fields" json:"fields,omitempty"`
+}
+
+type yamlFieldMatch struct {
+	Path    string `yaml:"path" json:"path"`
+	Pattern string `yaml:"pattern" json:"pattern"`
+}
+
+// deviceSelector is a compiled device selector
+type deviceSelector struct {
+	deviceIDs []string
+	types     []string
+	fields    []fieldMatcher
+	// IDs of the devices carrying one of the labels, nil when no label is specified
+	labelled []string
+}
+
+type fieldMatcher struct {
+	path    *jsonPath
+	pattern *regexp.Regexp
+}
+
+// compileDeviceSelector checks and compiles a selector.
+// labels are the device IDs listed under each label (device_labels).
+func compileDeviceSelector(targetName string, selector yamlDeviceSelector, labels map[string][]string) (*deviceSelector, error) {
+	if len(selector.DeviceIDs) == 0 && len(selector.Types) == 0 && len(selector.Labels) == 0 && len(selector.Fields) == 0 {
+		return nil, errDeviceSelector.New(targetName, "At least one of device_ids, types, labels and fields is required.")
+	}
+
+	compiled := &deviceSelector{
+		deviceIDs: selector.DeviceIDs,
+		types:     selector.Types,
+	}
+
+	for _, label := range selector.Labels {
+		ids, ok := labels[label]
+		if !ok {
+			return nil, errDeviceSelector.New(targetName, fmt.Sprintf("Label %s is not defined in device_labels.", label))
+		}
+		compiled.labelled = append(compiled.labelled, ids...)
+	}
+	if len(selector.Labels) > 0 && compiled.labelled == nil {
+		compiled.labelled = []string{}
+	}
+
+	for i, field := range selector.Fields {
+		path, err := compileJsonPath(field.Path)
+		if err != nil {
+			return nil, errDeviceSelector.Wrap(err, fmt.Sprintf("%s/fields[%d]/path", targetName, i), err)
+		}
+		pattern, err := regexp.Compile(field.Pattern)
+		if err != nil {
+			return nil, errDeviceSelector.Wrap(err, fmt.Sprintf("%s/fields[%d]/pattern", targetName, i), err)
+		}
+		compiled.fields = append(compiled.fields, fieldMatcher{path: path, pattern: pattern})
+	}
+
+	return compiled, nil
+}
+
+// compileDeviceSelectors compiles a list of selectors
+func compileDeviceSelectors(targetName string, selectors []yamlDeviceSelector, labels map[string][]string) ([]*deviceSelector, error) {
+	compiled := make([]*deviceSelector, 0, len(selectors))
+	for i, selector := range selectors {
+		c, err := compileDeviceSelector(fmt.Sprintf("%s[%d]", targetName, i), selector, labels)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// matches reports whether the device satisfies every criterion of the selector
+func (s *deviceSelector) matches(device *Device) bool {
+	if len(s.deviceIDs) > 0 && !slices.Contains(s.deviceIDs, device.ID) {
+		return false
+	}
+	if len(s.types) > 0 && !slices.Contains(s.types, device.Type) {
+		return false
+	}
+	if s.labelled != nil && !slices.Contains(s.labelled, device.ID) {
+		return false
+	}
+	for _, field := range s.fields {
+		if !field.matches(device) {
+			return false
+		}
+	}
+	return true
+}
+
+// Report whether a scalar value selected by the path matches the pattern
+func (f fieldMatcher) matches(device *Device) bool {
+	for _, value := range f.path.find(device.Raw) {
+		switch value.(type) {
+		case map[string]any, []any, nil:
+			continue
+		}
+		if f.pattern.MatchString(fmt.Sprint(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny returns the index of the first selector matching the device, or -1 when none matches
+func matchAny(selectors []*deviceSelector, device *Device) int {
+	for i, selector := range selectors {
+		if selector.matches(device) {
+			return i
+		}
+	}
+	return -1
+}
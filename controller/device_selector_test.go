@@ -0,0 +1,79 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"testing"
+)
+
+func Test_compileDeviceSelector(t *testing.T) {
+	labels := map[string][]string{"lab": {"dev1"}}
+
+	tests := []struct {
+		name     string
+		selector yamlDeviceSelector
+		wantErr  bool
+	}{
+		{"Normal case: Device IDs", yamlDeviceSelector{DeviceIDs: []string{"dev1"}}, false},
+		{"Normal case: Defined label", yamlDeviceSelector{Labels: []string{"lab"}}, false},
+		{"Error case: No criterion", yamlDeviceSelector{}, true},
+		{"Error case: Undefined label", yamlDeviceSelector{Labels: []string{"prod"}}, true},
+		{"Error case: Invalid path", yamlDeviceSelector{Fields: []yamlFieldMatch{{Path: "type", Pattern: "CPU"}}}, true},
+		{"Error case: Invalid pattern", yamlDeviceSelector{Fields: []yamlFieldMatch{{Path: "$.type", Pattern: "("}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileDeviceSelector("filter_configs/exclude[0]", tt.selector, labels)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("compileDeviceSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_deviceSelector_matches(t *testing.T) {
+	labels := map[string][]string{"lab": {"dev1", "dev3"}}
+	device := newDevice(map[string]any{
+		"deviceID":  "dev1",
+		"type":      "CPU",
+		"attribute": map[string]any{"serialNumber": "TEST-001", "cores": 8.0},
+	})
+
+	tests := []struct {
+		name     string
+		selector yamlDeviceSelector
+		want     bool
+	}{
+		{"Normal case: Device ID matches", yamlDeviceSelector{DeviceIDs: []string{"dev0", "dev1"}}, true},
+		{"Normal case: Device ID does not match", yamlDeviceSelector{DeviceIDs: []string{"dev2"}}, false},
+		{"Normal case: Type matches", yamlDeviceSelector{Types: []string{"CPU"}}, true},
+		{"Normal case: Label matches", yamlDeviceSelector{Labels: []string{"lab"}}, true},
+		{"Normal case: Field matches", yamlDeviceSelector{Fields: []yamlFieldMatch{{Path: "$.attribute.serialNumber", Pattern: "^TEST-"}}}, true},
+		{"Normal case: Numeric field matches", yamlDeviceSelector{Fields: []yamlFieldMatch{{Path: "$.attribute.cores", Pattern: "^8$"}}}, true},
+		{"Normal case: Missing field does not match", yamlDeviceSelector{Fields: []yamlFieldMatch{{Path: "$.attribute.vendor", Pattern: ".*"}}}, false},
+		{"Normal case: Every criterion must match", yamlDeviceSelector{Types: []string{"CPU"}, DeviceIDs: []string{"dev2"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := compileDeviceSelector("selector", tt.selector, labels)
+			if err != nil {
+				t.Fatalf("compileDeviceSelector() error = %v", err)
+			}
+			if got := selector.matches(&device); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
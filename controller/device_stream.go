@@ -0,0 +1,173 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Default upper limit of the size of the hw-control response (1 GiB)
+const defaultMaxResponseSize int64 = 1 << 30
+
+// Returned when the response is larger than collect_configs/max_response_size
+var errResponseTooLarge = errors.New("response exceeds the maximum size")
+
+// sizeLimitedReader reads from r until limit bytes have been read, then fails with errResponseTooLarge.
+// Unlike io.LimitReader, the truncation is reported instead of looking like the end of the response.
+type sizeLimitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (lr *sizeLimitedReader) Read(p []byte) (int, error) {
+	if lr.read > lr.limit {
+		return 0, errResponseTooLarge
+	}
+	// Read one byte more than the limit to detect an oversized response
+	if remaining := lr.limit - lr.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	if lr.read > lr.limit {
+		return n, errResponseTooLarge
+	}
+	return n, err
+}
+
+// decodeOutput decodes the hw-control response from r into output.
+// The entries of deviceList are decoded one at a time, so the raw response is never held in memory as a whole.
+func decodeOutput(r io.Reader, output *Output) error {
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+
+		switch key {
+		case "deviceList":
+			err = decodeDeviceList(dec, output)
+		case "incompleteDeviceList":
+			err = dec.Decode(&output.IncompleteDevices)
+		case "infoTimestamp":
+			err = dec.Decode(&output.TimeStamp)
+		default:
+			// Skip members that are not part of Output
+			var skipped json.RawMessage
+			err = dec.Decode(&skipped)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+
+	// As with json.Unmarshal, nothing may follow the top-level object
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("invalid data after top-level value: %v", err)
+	}
+
+	return nil
+}
+
+// Decode the deviceList array element by element
+func decodeDeviceList(dec *json.Decoder, output *Output) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		output.Devices = nil
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("deviceList is not an array: %v", token)
+	}
+
+	output.Devices = make([]Device, 0)
+	for dec.More() {
+		var device Device
+		if err := dec.Decode(&device); err != nil {
+			return err
+		}
+		output.Devices = append(output.Devices, device)
+	}
+
+	return expectDelim(dec, ']')
+}
+
+// Read the next token and check that it is the expected delimiter
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %v but got %v", want, token)
+	}
+	return nil
+}
+
+// encodeResources writes resources to w as a JSON array, encoding one element at a time,
+// so that the whole forward body is never held in memory.
+func encodeResources(w io.Writer, resources []any) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for i, resource := range resources {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(resource); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+// encodeCompressedResources writes resources to w as a JSON array compressed with the encoding
+func encodeCompressedResources(w io.Writer, resources []any, encoding string) error {
+	cw, err := newEncodingWriter(w, encoding)
+	if err != nil {
+		return err
+	}
+
+	err = encodeResources(cw, resources)
+	if err != nil {
+		return err
+	}
+
+	return cw.Close()
+}
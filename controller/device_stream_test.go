@@ -0,0 +1,147 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_decodeOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    Output
+		wantErr bool
+	}{
+		{
+			"Normal case: All members are decoded",
+			`{"deviceList": [{"deviceID": "dev1"}, {"deviceID": "dev2"}], "incompleteDeviceList": [{"deviceID": "dev3"}], "infoTimestamp": "2025-01-01T00:00:00Z"}`,
+			Output{
+				Devices:           []Device{newDevice(map[string]any{"deviceID": "dev1"}), newDevice(map[string]any{"deviceID": "dev2"})},
+				IncompleteDevices: []any{map[string]any{"deviceID": "dev3"}},
+				TimeStamp:         "2025-01-01T00:00:00Z",
+			},
+			false,
+		},
+		{
+			"Normal case: Unknown members are skipped",
+			`{"count": 1, "extra": {"a": [1, 2]}, "deviceList": [{"deviceID": "dev1"}]}`,
+			Output{Devices: []Device{newDevice(map[string]any{"deviceID": "dev1"})}},
+			false,
+		},
+		{
+			"Normal case: deviceList is null",
+			`{"deviceList": null}`,
+			Output{},
+			false,
+		},
+		{
+			"Error case: deviceList is not an array",
+			`{"deviceList": {"deviceID": "dev1"}}`,
+			Output{},
+			true,
+		},
+		{
+			"Error case: Response is not a JSON object",
+			`[]`,
+			Output{},
+			true,
+		},
+		{
+			"Error case: Response is truncated",
+			`{"deviceList": [{"deviceID": "dev1"}`,
+			Output{},
+			true,
+		},
+		{
+			"Error case: Data follows the top-level object",
+			`{"deviceList": []} {}`,
+			Output{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Output{}
+			err := decodeOutput(strings.NewReader(tt.body), &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeOutput() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sizeLimitedReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		limit   int64
+		wantErr error
+	}{
+		{"Normal case: Body is smaller than the limit", "12345", 10, nil},
+		{"Normal case: Body is equal to the limit", "12345", 5, nil},
+		{"Error case: Body is larger than the limit", "123456", 5, errResponseTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := io.ReadAll(&sizeLimitedReader{r: strings.NewReader(tt.body), limit: tt.limit})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("sizeLimitedReader error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_encodeResources(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []any
+		wantErr   bool
+	}{
+		{"Normal case: Empty list", []any{}, false},
+		{"Normal case: Several resources", []any{map[string]any{"deviceID": "dev1"}, map[string]any{"deviceID": "dev2"}}, false},
+		{"Error case: Unmarshalable resource", []any{make(chan int)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := encodeResources(&buf, tt.resources)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("encodeResources() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			want, _ := json.Marshal(tt.resources)
+			var got, wantValue any
+			json.Unmarshal(buf.Bytes(), &got)
+			json.Unmarshal(want, &wantValue)
+			if !reflect.DeepEqual(got, wantValue) {
+				t.Errorf("encodeResources() = %s, want %s", buf.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_newDevice(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  map[string]any
+		want Device
+	}{
+		{
+			"Normal case: All typed fields are extracted",
+			map[string]any{
+				"deviceID": "dev1",
+				"type":     "CPU",
+				"status":   map[string]any{"state": "Enabled", "health": "OK"},
+				"links":    []any{map[string]any{"type": "memory", "deviceID": "dev2"}},
+			},
+			Device{
+				ID:     "dev1",
+				Type:   "CPU",
+				Status: &DeviceStatus{State: "Enabled", Health: "OK"},
+				Links:  []DeviceLink{{Type: "memory", DeviceID: "dev2"}},
+			},
+		},
+		{
+			"Normal case: Fields of an unexpected type are left empty",
+			map[string]any{
+				"deviceID": 1,
+				"status":   map[string]any{"state": 1},
+				"links":    []any{"dev2"},
+			},
+			Device{Status: &DeviceStatus{}},
+		},
+		{
+			"Normal case: status that is not a map is left nil",
+			map[string]any{"status": "aaa"},
+			Device{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newDevice(tt.raw)
+			tt.want.Raw = tt.raw
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newDevice() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDevice_MarshalJSON(t *testing.T) {
+	original := `{"deviceID":"dev1","extra":{"nested":[1,"a"]},"status":{"health":"OK","state":"Enabled"},"type":"CPU"}`
+
+	var device Device
+	if err := json.Unmarshal([]byte(original), &device); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	got, err := json.Marshal(device)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(got) != original {
+		t.Errorf("json.Marshal() = %s, want %s", got, original)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// dryRunReport is what a sync would have sent. The payloads are the request bodies before compression,
+// the alerts are listed for every sink receiving them.
+type dryRunReport struct {
+	DryRun  bool            `json:"dryRun"`
+	Result  syncResult      `json:"result"`
+	Forward dryRunRequest   `json:"forward"`
+	Alerts  []dryRunRequest `json:"alerts"`
+}
+
+type dryRunRequest struct {
+	AlertName       string          `json:"alertName,omitempty"`
+	Sink            string          `json:"sink,omitempty"`
+	TargetUrl       string          `json:"targetUrl"`
+	ContentEncoding string          `json:"contentEncoding,omitempty"`
+	Payload         json.RawMessage `json:"payload"`
+}
+
+// Build the report of a dry run from the plan of the sync
+func newDryRunReport(ctx context.Context, settings *yamlContent, plan *syncPlan) (*dryRunReport, error) {
+	var forward bytes.Buffer
+	if err := encodeResources(&forward, plan.resources); err != nil {
+		return nil, errEncodePayload.Wrap(err, "the forward payload", err)
+	}
+
+	report := &dryRunReport{
+		DryRun: true,
+		Result: plan.result,
+		Forward: dryRunRequest{
+			TargetUrl:       plan.forward.TargetUrl,
+			ContentEncoding: plan.forward.ContentEncoding,
+			Payload:         forward.Bytes(),
+		},
+		Alerts: make([]dryRunRequest, 0, len(plan.alerts)),
+	}
+
+	for _, alert := range plan.alerts {
+		for _, sink := range sinksFor(&settings.NotificationConfigs, alert.kind()) {
+			body, err := notificationBody(sink, alert)
+			if err != nil {
+				return nil, errEncodePayload.Wrap(err, fmt.Sprintf("the payload of %s for %s", alert.name, sink.name), err)
+			}
+			report.Alerts = append(report.Alerts, dryRunRequest{
+				AlertName:       alert.name,
+				Sink:            sink.name,
+				TargetUrl:       sink.targetUrl,
+				ContentEncoding: sink.contentEncoding,
+				Payload:         body,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// DryRun runs a sync in dry-run mode from the command line and writes the report to w as indented JSON.
+// Nothing is forwarded nor alerted.
+func DryRun(ctx context.Context, w io.Writer) error {
+	settings := yamlContent{}
+	err := loadConfig(yamlFilePath, &settings)
+	if err != nil {
+		return err
+	}
+
+	applyLoggingConfig(&settings.LoggingConfigs)
+
+	ctx, cancel := context.WithTimeout(ctx, toDuration(settings.SyncConfigs.TimeOut))
+	defer cancel()
+
+	plan, err := planSync(ctx, &settings, nil)
+	if err != nil {
+		return err
+	}
+
+	report, err := newDryRunReport(ctx, &settings, plan)
+	if err != nil {
+		return err
+	}
+	plan.result.log(ctx)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
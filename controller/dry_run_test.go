@@ -0,0 +1,147 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_newDryRunReport(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"deviceList": [
+			{"deviceID": "dev1", "type": "CPU", "status": {"state": "Enabled", "health": "OK"}},
+			{"deviceID": "dev2", "type": "CPU", "status": {"state": "Disabled", "health": "OK"}}
+		], "infoTimestamp": "2025-01-01T00:00:00Z"}`))
+	}))
+	defer testServer.Close()
+
+	settings := yamlContent{}
+	if err := loadConfig("testdata/exporter.yaml", &settings); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	settings.CollectConfigs.TargetUrl = testServer.URL
+
+	plan, err := planSync(context.Background(), &settings, nil)
+	if err != nil {
+		t.Fatalf("planSync() error = %v", err)
+	}
+	report, err := newDryRunReport(context.Background(), &settings, plan)
+	if err != nil {
+		t.Fatalf("newDryRunReport() error = %v", err)
+	}
+
+	var forwarded []map[string]any
+	if err := json.Unmarshal(report.Forward.Payload, &forwarded); err != nil {
+		t.Fatalf("forward payload is not a JSON array: %v", err)
+	}
+	if len(forwarded) != 2 || report.Forward.TargetUrl != settings.ForwardConfigs.TargetUrl {
+		t.Errorf("forward = %s to %s", report.Forward.Payload, report.Forward.TargetUrl)
+	}
+
+	if len(report.Alerts) != 1 || report.Alerts[0].AlertName != abnormalStatusDeviceList {
+		t.Fatalf("alerts = %v, want one %s", report.Alerts, abnormalStatusDeviceList)
+	}
+	var alert alertContentList
+	if err := json.Unmarshal(report.Alerts[0].Payload, &alert); err != nil {
+		t.Fatalf("alert payload is not an alert: %v", err)
+	}
+	if alert[0].Labels.Alertname != abnormalStatusDeviceList {
+		t.Errorf("alert payload = %s", report.Alerts[0].Payload)
+	}
+}
+
+func TestSyncDevices_dryRunInvalid(t *testing.T) {
+	w := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(w)
+	ginContext.Request = httptest.NewRequest(http.MethodPost, "/cdim/api/v1/devices/sync?dryRun=maybe", nil)
+
+	SyncDevices(ginContext)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("SyncDevices() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func Test_newDryRunReport_webhook(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"deviceList": [
+			{"deviceID": "dev1", "type": "CPU", "status": {"state": "Disabled", "health": "OK"}}
+		], "infoTimestamp": "2025-01-01T00:00:00Z"}`))
+	}))
+	defer testServer.Close()
+
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{"Normal case: Template producing JSON", `{"kind": "{{.Kind}}", "devices": {{json .Entries}}}`, false},
+		{"Error case: Template producing something else than JSON for the devices", `{{if eq (index .Entries 0).deviceID "sample"}}{}{{else}}alert {{.Summary}}{{end}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := yamlContent{}
+			if err := loadConfig("testdata/exporter.yaml", &settings); err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			settings.CollectConfigs.TargetUrl = testServer.URL
+			settings.NotificationConfigs = yamlNotificationConfig{Sinks: []yamlNotificationSink{
+				{Name: "hook", Type: sinkWebhook, TargetUrl: "http://localhost/hook", TimeOut: settings.AlertConfigs.TimeOut, Template: tt.template},
+			}}
+			if err := validNotificationConfig(&settings.NotificationConfigs, &settings.AlertConfigs); err != nil {
+				t.Fatalf("validNotificationConfig() error = %v", err)
+			}
+
+			plan, err := planSync(context.Background(), &settings, nil)
+			if err != nil {
+				t.Fatalf("planSync() error = %v", err)
+			}
+			report, err := newDryRunReport(context.Background(), &settings, plan)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newDryRunReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), "hook") {
+					t.Errorf("newDryRunReport() error = %v, want the sink named", err)
+				}
+				return
+			}
+
+			if _, err := json.Marshal(report); err != nil {
+				t.Fatalf("the report cannot be marshaled: %v", err)
+			}
+			var hook map[string]any
+			for _, alert := range report.Alerts {
+				if alert.Sink == "hook" {
+					if err := json.Unmarshal(alert.Payload, &hook); err != nil {
+						t.Fatalf("webhook payload = %s: %v", alert.Payload, err)
+					}
+				}
+			}
+			if devices, _ := hook["devices"].([]any); hook["kind"] != abnormalStatusDeviceList || len(devices) != 1 {
+				t.Errorf("webhook payload = %v, want the abnormal device", hook)
+			}
+		})
+	}
+}
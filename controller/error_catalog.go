@@ -0,0 +1,149 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Categories of the errors
+const (
+	categoryConfig   string = "config"
+	categoryRequest  string = "request"
+	categoryCollect  string = "collect"
+	categoryInternal string = "internal"
+)
+
+// errorDefinition is an entry of the error catalog.
+// Its code and meaning are stable, clients can rely on them.
+//
+// A definition is also an error, so that errors.Is(err, errTimeout) reports whether err has the code of errTimeout.
+type errorDefinition struct {
+	Code   string
+	Status int
+	// Short description of the error, the same for every occurrence
+	Title string
+	// fmt template of the message
+	Template string
+	// Whether the same request may succeed when it is retried
+	Retryable bool
+	Category  string
+}
+
+// The error catalog. Codes 0003 to 0005 are not used anymore and are not reassigned.
+var (
+	errReadFile            = defineError("0001", http.StatusInternalServerError, "Failed to read file.", "Failed to read file.", false, categoryConfig)
+	errUnmarshalYaml       = defineError("0002", http.StatusInternalServerError, "Failed to unmarshal yaml.", "Failed to unmarshal yaml.", false, categoryConfig)
+	errGetRequest          = defineError("0006", http.StatusInternalServerError, "Get request failure.", "Get request failure.", true, categoryCollect)
+	errCollectTarget       = defineError("0007", http.StatusInternalServerError, "Collect target failure.", "Collect target failure.", true, categoryCollect)
+	errReadResponse        = defineError("0008", http.StatusInternalServerError, "Failed to read response.", "Failed to read response.", true, categoryCollect)
+	errUnmarshalResponse   = defineError("0009", http.StatusInternalServerError, "Failed to unmarshal response.", "Failed to unmarshal response.", false, categoryCollect)
+	errSettingRequired     = defineError("0010", http.StatusInternalServerError, "Setting is required.", "%s setting is required.", false, categoryConfig)
+	errUrlFormat           = defineError("0011", http.StatusInternalServerError, "Format of the url is invalid.", "%s Format of the url is invalid.", false, categoryConfig)
+	errOutOfRange          = defineError("0012", http.StatusInternalServerError, "Value is out of range.", "%s value is out of range.", false, categoryConfig)
+	errValueNil            = defineError("0013", http.StatusInternalServerError, "Value is nil.", "%s value is nil.", false, categoryConfig)
+	errValueBlank          = defineError("0014", http.StatusInternalServerError, "Value is blank.", "%s value is blank.", false, categoryConfig)
+	errTimeout             = defineError("0015", http.StatusGatewayTimeout, "Request timed out.", "Request timed out.", true, categoryCollect)
+	errCancelled           = defineError("0016", http.StatusInternalServerError, "Request was cancelled.", "Request was cancelled.", true, categoryCollect)
+	errResponseSize        = defineError("0017", http.StatusInternalServerError, "Response exceeds the maximum size.", "Response exceeds the maximum size.", false, categoryCollect)
+	errEncoding            = defineError("0018", http.StatusInternalServerError, "Encoding is not supported.", "%s value is not a supported encoding.", false, categoryConfig)
+	errDecompress          = defineError("0019", http.StatusInternalServerError, "Failed to decompress response.", "Failed to decompress response.", false, categoryCollect)
+	errDeviceSchema        = defineError("0020", http.StatusInternalServerError, "Failed to compile the device schema.", "Failed to compile the device schema. %s", false, categoryConfig)
+	errTransformRule       = defineError("0021", http.StatusInternalServerError, "Transform rule is invalid.", "%s is invalid. %s", false, categoryConfig)
+	errDeviceSelector      = defineError("0022", http.StatusInternalServerError, "Device selector is invalid.", "%s is invalid. %s", false, categoryConfig)
+	errMaintenanceConfig   = defineError("0023", http.StatusInternalServerError, "Maintenance window is invalid.", "%s is invalid. %s", false, categoryConfig)
+	errMaintenanceNotFound = defineError("0024", http.StatusNotFound, "Maintenance window does not exist.", "Maintenance window %s does not exist.", false, categoryRequest)
+	errMaintenanceConflict = defineError("0025", http.StatusConflict, "Maintenance window cannot be deleted.", "Maintenance window %s is defined in the settings and cannot be deleted through the API.", false, categoryRequest)
+	errQueryParameter      = defineError("0026", http.StatusBadRequest, "Query parameter is invalid.", "%s must be true or false.", false, categoryRequest)
+	errEncodePayload       = defineError("0027", http.StatusInternalServerError, "Failed to encode a payload.", "Failed to encode %s. %s", false, categoryInternal)
+	errSyncTarget          = defineError("0028", http.StatusBadRequest, "Request body is invalid.", "Request body is invalid. %s", false, categoryRequest)
+	errNotificationSink    = defineError("0029", http.StatusInternalServerError, "Notification sink is invalid.", "%s is invalid. %s", false, categoryConfig)
+	errMaintenanceRequest  = defineError("0030", http.StatusBadRequest, "Maintenance window is invalid.", "%s is invalid. %s", false, categoryRequest)
+	errInternal            = defineError("0031", http.StatusInternalServerError, "Internal error.", "Internal error. %s", true, categoryInternal)
+	errLogLevel            = defineError("0032", http.StatusInternalServerError, "Log level is invalid.", "%s value is not a log level. It must be one of %s.", false, categoryConfig)
+	errLogLevelRequest     = defineError("0033", http.StatusBadRequest, "Log level is invalid.", "%s is invalid. %s", false, categoryRequest)
+	errAuditConfig         = defineError("0034", http.StatusInternalServerError, "Audit setting is invalid.", "%s is invalid. %s", false, categoryConfig)
+	errHistoryQuery        = defineError("0035", http.StatusBadRequest, "Query parameter is invalid.", "%s is invalid. %s", false, categoryRequest)
+	errDeviceNotFound      = defineError("0036", http.StatusNotFound, "Device does not exist.", "Device %s does not exist in the latest snapshot.", false, categoryRequest)
+	errEventQuery          = defineError("0037", http.StatusBadRequest, "Last event ID is invalid.", "Last event ID %s is invalid. It must be a non-negative integer.", false, categoryRequest)
+)
+
+// errorCatalog lists the definitions by code
+var errorCatalog = map[string]*errorDefinition{}
+
+// Register a definition in the catalog
+func defineError(code string, status int, title string, template string, retryable bool, category string) *errorDefinition {
+	if _, ok := errorCatalog[code]; ok {
+		panic("duplicate error code " + code)
+	}
+	definition := &errorDefinition{Code: code, Status: status, Title: title, Template: template, Retryable: retryable, Category: category}
+	errorCatalog[code] = definition
+	return definition
+}
+
+// Error implements the error interface, so that a definition can be the target of errors.Is
+func (d *errorDefinition) Error() string {
+	return fmt.Sprintf("code = %s title = %s", d.Code, d.Title)
+}
+
+// New returns an error of the definition, with the message formatted from the template and args
+func (d *errorDefinition) New(args ...any) error {
+	return d.Wrap(nil, args...)
+}
+
+// Wrap returns an error of the definition caused by cause, which errors.Is and errors.As can reach
+func (d *errorDefinition) Wrap(cause error, args ...any) error {
+	message := d.Template
+	if len(args) > 0 {
+		message = fmt.Sprintf(d.Template, args...)
+	}
+	return &ExpError{
+		StatusCode: d.Status,
+		Code:       d.Code,
+		Message:    message,
+		Retryable:  d.Retryable,
+		Category:   d.Category,
+		cause:      cause,
+	}
+}
+
+// writeProblem responds with the error as an RFC 7807 problem detail (application/problem+json).
+// The code and message members of the former error responses are kept as extension members.
+// An error that is not an ExpError is reported as errInternal.
+func writeProblem(c *gin.Context, err error) {
+	expErr := asExpError(err)
+	definition := errorCatalog[expErr.Code]
+	title := expErr.Message
+	if definition != nil {
+		title = definition.Title
+	}
+
+	c.Header("Content-Type", "application/problem+json")
+	c.JSON(expErr.StatusCode, gin.H{
+		"type":      "urn:cdim:configuration-exporter:error:" + expErr.Code,
+		"title":     title,
+		"status":    expErr.StatusCode,
+		"detail":    expErr.Message,
+		"instance":  c.Request.URL.Path,
+		"code":      expErr.Code,
+		"message":   expErr.Message,
+		"retryable": expErr.Retryable,
+		"category":  expErr.Category,
+		"requestId": RequestIdOf(c),
+	})
+}
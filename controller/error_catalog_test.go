@@ -0,0 +1,120 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_errorDefinition_Wrap(t *testing.T) {
+	cause := context.DeadlineExceeded
+	err := errTimeout.Wrap(errGetRequest.Wrap(cause))
+
+	tests := []struct {
+		name   string
+		target error
+		want   bool
+	}{
+		{"Normal case: Outer definition", errTimeout, true},
+		{"Normal case: Wrapped definition", errGetRequest, true},
+		{"Normal case: Wrapped cause", context.DeadlineExceeded, true},
+		{"Error case: Other definition", errCancelled, false},
+		{"Error case: Other cause", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(err, tt.target); got != tt.want {
+				t.Errorf("errors.Is() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var expErr *ExpError
+	if !errors.As(err, &expErr) || expErr.Code != "0015" || !expErr.Retryable || expErr.Category != categoryCollect {
+		t.Errorf("errors.As() = %+v, want the error of code 0015", expErr)
+	}
+}
+
+func Test_asExpError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+	}{
+		{"Normal case: ExpError", errValueBlank.New("target"), "0014"},
+		{"Normal case: Legacy constructor", ExpErrorNew(http.StatusInternalServerError, "0001", "Failed to read file."), "0001"},
+		{"Normal case: Other error is internal", errors.New("failure"), "0031"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := asExpError(tt.err)
+			if got.Code != tt.wantCode {
+				t.Errorf("asExpError() code = %s, want %s", got.Code, tt.wantCode)
+			}
+			if got.Category != errorCatalog[tt.wantCode].Category {
+				t.Errorf("asExpError() category = %s, want %s", got.Category, errorCatalog[tt.wantCode].Category)
+			}
+		})
+	}
+}
+
+func Test_writeProblem(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"Normal case: Request error", errMaintenanceNotFound.New("api-1"), http.StatusNotFound, "0024"},
+		{"Normal case: Other error", errors.New("failure"), http.StatusInternalServerError, "0031"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodGet, "/cdim/api/v1/maintenance-windows/api-1", nil)
+
+			writeProblem(ginContext, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("writeProblem() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("writeProblem() Content-Type = %s, want application/problem+json", got)
+			}
+
+			var problem map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if problem["type"] != "urn:cdim:configuration-exporter:error:"+tt.wantCode || problem["code"] != tt.wantCode {
+				t.Errorf("writeProblem() type = %v, code = %v, want the code %s", problem["type"], problem["code"], tt.wantCode)
+			}
+			if problem["status"] != float64(tt.wantStatus) || problem["instance"] != "/cdim/api/v1/maintenance-windows/api-1" {
+				t.Errorf("writeProblem() status = %v, instance = %v", problem["status"], problem["instance"])
+			}
+			if problem["requestId"] == "" || problem["requestId"] != ginContext.GetString(requestIdKey) {
+				t.Errorf("writeProblem() requestId = %v, want %s", problem["requestId"], ginContext.GetString(requestIdKey))
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Defaults of the shared HTTP transport, the same as http.DefaultTransport
+const (
+	defaultMaxIdleConns          int = 100
+	defaultMaxIdleConnsPerHost   int = 2
+	defaultIdleConnTimeout       int = 90
+	defaultDialTimeout           int = 30
+	defaultKeepAlive             int = 30
+	defaultTlsHandshakeTimeout   int = 10
+	defaultResponseHeaderTimeout int = 0
+	maxIdleConnsLimit            int = 10000
+)
+
+type yamlHttpClientConfig struct {
+	MaxIdleConns          *int                `yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost   *int                `yaml:"max_idle_conns_per_host"`
+	IdleConnTimeout       *int                `yaml:"idle_conn_timeout"`
+	DialTimeout           *int                `yaml:"dial_timeout"`
+	KeepAlive             *int                `yaml:"keep_alive"`
+	TlsHandshakeTimeout   *int                `yaml:"tls_handshake_timeout"`
+	ResponseHeaderTimeout *int                `yaml:"response_header_timeout"`
+	DisableKeepAlives     bool                `yaml:"disable_keep_alives"`
+	Http2                 *bool               `yaml:"http2"`
+	Proxy                 yamlHttpProxyConfig `yaml:"proxy"`
+}
+
+// When url is omitted, the proxy is taken from the environment (HTTP_PROXY, HTTPS_PROXY and NO_PROXY).
+type yamlHttpProxyConfig struct {
+	Url     string `yaml:"url"`
+	NoProxy string `yaml:"no_proxy"`
+}
+
+// The client shared by the collect, forward and alert targets, and the settings it was built from.
+// It is rebuilt only when the settings change, so connections are reused across syncs.
+var sharedClient struct {
+	sync.Mutex
+	settings yamlHttpClientConfig
+	client   *http.Client
+}
+
+// Check the http_client_configs settings and fill in the defaults
+func validHttpClientConfig(settings *yamlHttpClientConfig) error {
+	var err error
+
+	settings.MaxIdleConns, err = validConfigCount("http_client_configs/max_idle_conns", settings.MaxIdleConns, defaultMaxIdleConns, maxIdleConnsLimit)
+	if err != nil {
+		return err
+	}
+
+	settings.MaxIdleConnsPerHost, err = validConfigCount("http_client_configs/max_idle_conns_per_host", settings.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost, maxIdleConnsLimit)
+	if err != nil {
+		return err
+	}
+
+	settings.IdleConnTimeout, err = validConfigCount("http_client_configs/idle_conn_timeout", settings.IdleConnTimeout, defaultIdleConnTimeout, maxTimeout)
+	if err != nil {
+		return err
+	}
+
+	settings.DialTimeout, err = validConfigCount("http_client_configs/dial_timeout", settings.DialTimeout, defaultDialTimeout, maxTimeout)
+	if err != nil {
+		return err
+	}
+
+	settings.KeepAlive, err = validConfigCount("http_client_configs/keep_alive", settings.KeepAlive, defaultKeepAlive, maxTimeout)
+	if err != nil {
+		return err
+	}
+
+	settings.TlsHandshakeTimeout, err = validConfigCount("http_client_configs/tls_handshake_timeout", settings.TlsHandshakeTimeout, defaultTlsHandshakeTimeout, maxTimeout)
+	if err != nil {
+		return err
+	}
+
+	settings.ResponseHeaderTimeout, err = validConfigCount("http_client_configs/response_header_timeout", settings.ResponseHeaderTimeout, defaultResponseHeaderTimeout, maxTimeout)
+	if err != nil {
+		return err
+	}
+
+	if settings.Http2 == nil {
+		http2 := true
+		settings.Http2 = &http2
+	}
+
+	if settings.Proxy.Url != "" {
+		err = validConfigUrl("http_client_configs/proxy/url", settings.Proxy.Url)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Check the range of a count or a duration in seconds where 0 is allowed.
+// The default value is set when it is omitted.
+func validConfigCount(targetName string, targetValue *int, defaultValue int, maxValue int) (*int, error) {
+	if targetValue == nil {
+		return &defaultValue, nil
+	}
+	if *targetValue < 0 || *targetValue > maxValue {
+		return nil, errOutOfRange.New(targetName)
+	}
+
+	return targetValue, nil
+}
+
+// httpClientFor returns the HTTP client shared by all outbound calls.
+// A new client is built when the settings differ from those of the current one,
+// and the idle connections of the previous client are closed.
+func httpClientFor(settings *yamlHttpClientConfig) *http.Client {
+	sharedClient.Lock()
+	defer sharedClient.Unlock()
+
+	if sharedClient.client != nil && reflect.DeepEqual(sharedClient.settings, *settings) {
+		return sharedClient.client
+	}
+
+	if sharedClient.client != nil {
+		sharedClient.client.CloseIdleConnections()
+	}
+	sharedClient.settings = *settings
+	sharedClient.client = newHttpClient(settings)
+
+	return sharedClient.client
+}
+
+// newHttpClient builds an HTTP client from the http_client_configs settings.
+// The client has no overall timeout, the deadline of each call is given by its context.
+func newHttpClient(settings *yamlHttpClientConfig) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   toDuration(orDefault(settings.DialTimeout, defaultDialTimeout)),
+		KeepAlive: toDuration(orDefault(settings.KeepAlive, defaultKeepAlive)),
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxyFunc(&settings.Proxy),
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          *orDefault(settings.MaxIdleConns, defaultMaxIdleConns),
+		MaxIdleConnsPerHost:   *orDefault(settings.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost),
+		IdleConnTimeout:       toDuration(orDefault(settings.IdleConnTimeout, defaultIdleConnTimeout)),
+		TLSHandshakeTimeout:   toDuration(orDefault(settings.TlsHandshakeTimeout, defaultTlsHandshakeTimeout)),
+		ResponseHeaderTimeout: toDuration(orDefault(settings.ResponseHeaderTimeout, defaultResponseHeaderTimeout)),
+		ExpectContinueTimeout: 1 * time.Second,
+		DisableKeepAlives:     settings.DisableKeepAlives,
+		ForceAttemptHTTP2:     true,
+	}
+
+	// A non-nil empty TLSNextProto disables HTTP/2
+	if settings.Http2 != nil && !*settings.Http2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// Return the proxy selection function of the proxy settings
+func proxyFunc(settings *yamlHttpProxyConfig) func(*http.Request) (*url.URL, error) {
+	if settings.Url == "" {
+		return http.ProxyFromEnvironment
+	}
+
+	proxyConfig := httpproxy.Config{
+		HTTPProxy:  settings.Url,
+		HTTPSProxy: settings.Url,
+		NoProxy:    settings.NoProxy,
+	}
+	selectProxy := proxyConfig.ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return selectProxy(req.URL)
+	}
+}
+
+// Return value, or a pointer to defaultValue when it is nil
+func orDefault(value *int, defaultValue int) *int {
+	if value == nil {
+		return &defaultValue
+	}
+	return value
+}
+
+// Return value, or a pointer to defaultValue when it is nil
+func orDefault64(value *int64, defaultValue int64) *int64 {
+	if value == nil {
+		return &defaultValue
+	}
+	return value
+}
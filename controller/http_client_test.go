@@ -0,0 +1,114 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_validHttpClientConfig(t *testing.T) {
+	negative := -1
+	tooMany := maxIdleConnsLimit + 1
+
+	tests := []struct {
+		name     string
+		settings yamlHttpClientConfig
+		wantErr  bool
+	}{
+		{"Normal case: Defaults are set when everything is omitted", yamlHttpClientConfig{}, false},
+		{"Error case: max_idle_conns is negative", yamlHttpClientConfig{MaxIdleConns: &negative}, true},
+		{"Error case: max_idle_conns_per_host is greater than the upper limit", yamlHttpClientConfig{MaxIdleConnsPerHost: &tooMany}, true},
+		{"Error case: dial_timeout is negative", yamlHttpClientConfig{DialTimeout: &negative}, true},
+		{"Error case: proxy/url is not in URL format", yamlHttpClientConfig{Proxy: yamlHttpProxyConfig{Url: "proxy"}}, true},
+		{"Normal case: proxy/url is in URL format", yamlHttpClientConfig{Proxy: yamlHttpProxyConfig{Url: "http://proxy:3128"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validHttpClientConfig(&tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validHttpClientConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (tt.settings.MaxIdleConns == nil || tt.settings.Http2 == nil) {
+				t.Errorf("validHttpClientConfig() defaults were not set: %+v", tt.settings)
+			}
+		})
+	}
+}
+
+func Test_httpClientFor(t *testing.T) {
+	settings := yamlHttpClientConfig{}
+	validHttpClientConfig(&settings)
+
+	first := httpClientFor(&settings)
+	if second := httpClientFor(&settings); second != first {
+		t.Error("httpClientFor() built a new client for the same settings")
+	}
+
+	idleConnTimeout := 1
+	changed := settings
+	changed.IdleConnTimeout = &idleConnTimeout
+	if third := httpClientFor(&changed); third == first {
+		t.Error("httpClientFor() reused the client after the settings changed")
+	}
+}
+
+func Test_newHttpClient(t *testing.T) {
+	disabled := false
+	responseHeaderTimeout := 3
+
+	client := newHttpClient(&yamlHttpClientConfig{Http2: &disabled, ResponseHeaderTimeout: &responseHeaderTimeout})
+	transport := client.Transport.(*http.Transport)
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Error("newHttpClient() did not disable HTTP/2")
+	}
+	if transport.ResponseHeaderTimeout != 3*time.Second {
+		t.Errorf("newHttpClient() ResponseHeaderTimeout = %v, want %v", transport.ResponseHeaderTimeout, 3*time.Second)
+	}
+	if client.Timeout != 0 {
+		t.Errorf("newHttpClient() Timeout = %v, want no timeout", client.Timeout)
+	}
+}
+
+func Test_proxyFunc(t *testing.T) {
+	selectProxy := proxyFunc(&yamlHttpProxyConfig{Url: "http://proxy:3128", NoProxy: "internal.example"})
+
+	tests := []struct {
+		name      string
+		target    string
+		wantProxy string
+	}{
+		{"Normal case: Request goes through the proxy", "http://hw-control.example/devices", "http://proxy:3128"},
+		{"Normal case: Host in no_proxy is requested directly", "http://internal.example/devices", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxyUrl, err := selectProxy(httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if err != nil {
+				t.Fatalf("proxyFunc() error = %v", err)
+			}
+			got := ""
+			if proxyUrl != nil {
+				got = proxyUrl.String()
+			}
+			if got != tt.wantProxy {
+				t.Errorf("proxyFunc() = %v, want %v", got, tt.wantProxy)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inventorySnapshot is the output of hw-control collected by the latest full sync
+type inventorySnapshot struct {
+	InfoTimestamp string
+	CollectedAt   time.Time
+	Devices       []Device
+}
+
+// Snapshot of the latest full sync. A targeted sync collects part of the devices only, so it leaves it unchanged.
+var inventory struct {
+	sync.RWMutex
+	snapshot inventorySnapshot
+}
+
+// inventoryList is the response of the inventory API
+type inventoryList struct {
+	InfoTimestamp string    `json:"infoTimestamp"`
+	CollectedAt   time.Time `json:"collectedAt"`
+	Count         int       `json:"count"`
+	Devices       []Device  `json:"devices"`
+}
+
+// inventoryDevice is the response of the inventory API for a single device
+type inventoryDevice struct {
+	InfoTimestamp string    `json:"infoTimestamp"`
+	CollectedAt   time.Time `json:"collectedAt"`
+	Device        Device    `json:"device"`
+}
+
+// Replace the snapshot with the output collected by a sync. It returns the previous snapshot.
+func updateInventory(output Output) inventorySnapshot {
+	inventory.Lock()
+	defer inventory.Unlock()
+
+	previous := inventory.snapshot
+	inventory.snapshot = inventorySnapshot{
+		InfoTimestamp: output.TimeStamp,
+		CollectedAt:   time.Now().UTC(),
+		Devices:       output.Devices,
+	}
+	return previous
+}
+
+// Return the snapshot of the latest full sync. Its devices must not be modified.
+func currentInventory() inventorySnapshot {
+	inventory.RLock()
+	defer inventory.RUnlock()
+	return inventory.snapshot
+}
+
+// Return the device of the snapshot with the ID
+func (s *inventorySnapshot) device(id string) (*Device, error) {
+	index := slices.IndexFunc(s.Devices, func(d Device) bool { return d.ID == id })
+	if index < 0 {
+		return nil, errDeviceNotFound.New(id)
+	}
+	return &s.Devices[index], nil
+}
+
+// inventoryFilter keeps the devices of which every specified field is one of its values
+type inventoryFilter struct {
+	types   []string
+	states  []string
+	healths []string
+}
+
+// Read the filters of the query parameters type, state and health. Each can be repeated.
+func parseInventoryFilter(c *gin.Context) inventoryFilter {
+	return inventoryFilter{
+		types:   c.QueryArray("type"),
+		states:  c.QueryArray("state"),
+		healths: c.QueryArray("health"),
+	}
+}
+
+// matches reports whether the device passes the filter. A device without status matches no state nor health.
+func (f inventoryFilter) matches(device *Device) bool {
+	if len(f.types) > 0 && !slices.Contains(f.types, device.Type) {
+		return false
+	}
+	if len(f.states) == 0 && len(f.healths) == 0 {
+		return true
+	}
+	if device.Status == nil {
+		return false
+	}
+	return (len(f.states) == 0 || slices.Contains(f.states, device.Status.State)) &&
+		(len(f.healths) == 0 || slices.Contains(f.healths, device.Status.Health))
+}
+
+// writeWithETag writes the body as JSON with a strong ETag of its content.
+// When If-None-Match lists the ETag, only 304 Not Modified is returned.
+func writeWithETag(c *gin.Context, body any) {
+	content, err := json.Marshal(body)
+	if err != nil {
+		err = errInternal.Wrap(err, err)
+		logFor(c.Request.Context()).Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	sum := sha256.Sum256(content)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", content)
+}
+
+// Report whether an If-None-Match header lists the ETag, with the weak comparison of RFC 9110
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDevices returns the devices collected by the latest full sync, without querying hw-control.
+//
+// Query parameters:
+//   - type, state, health: keep the devices of which the field is one of the values, each can be repeated.
+//
+// Response Codes:
+//   - 200 OK: Returned with the devices and the infoTimestamp of the snapshot. It is empty until a sync has collected devices.
+//   - 304 Not Modified: Returned when If-None-Match lists the ETag of the response.
+func GetDevices(c *gin.Context) {
+	snapshot := currentInventory()
+	filter := parseInventoryFilter(c)
+
+	list := inventoryList{InfoTimestamp: snapshot.InfoTimestamp, CollectedAt: snapshot.CollectedAt, Devices: []Device{}}
+	for i := range snapshot.Devices {
+		if filter.matches(&snapshot.Devices[i]) {
+			list.Devices = append(list.Devices, snapshot.Devices[i])
+		}
+	}
+	list.Count = len(list.Devices)
+
+	writeWithETag(c, list)
+}
+
+// GetDevice returns a device collected by the latest full sync, without querying hw-control.
+//
+// Response Codes:
+//   - 200 OK: Returned with the device and the infoTimestamp of the snapshot.
+//   - 304 Not Modified: Returned when If-None-Match lists the ETag of the response.
+//   - 404 Not Found: Returned when the snapshot has no device with the ID.
+func GetDevice(c *gin.Context) {
+	snapshot := currentInventory()
+	device, err := snapshot.device(c.Param("id"))
+	if err != nil {
+		logFor(c.Request.Context()).Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	writeWithETag(c, inventoryDevice{InfoTimestamp: snapshot.InfoTimestamp, CollectedAt: snapshot.CollectedAt, Device: *device})
+}
@@ -0,0 +1,177 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Replace the inventory with the devices for the test
+func setInventory(t *testing.T, devices string) {
+	output := Output{TimeStamp: "2025-01-01T00:00:00Z"}
+	if err := json.Unmarshal([]byte(devices), &output.Devices); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	updateInventory(output)
+	t.Cleanup(func() { updateInventory(Output{}) })
+}
+
+const inventoryDevices = `[
+	{"deviceID": "cpu1", "type": "CPU", "status": {"state": "Enabled", "health": "OK"}},
+	{"deviceID": "cpu2", "type": "CPU", "status": {"state": "Disabled", "health": "Critical"}},
+	{"deviceID": "mem1", "type": "memory", "status": {"state": "Enabled", "health": "Warning"}},
+	{"deviceID": "nic1", "type": "networkInterface"}
+]`
+
+func deviceIds(devices []Device) string {
+	ids := make([]string, 0, len(devices))
+	for _, device := range devices {
+		ids = append(ids, device.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestGetDevices(t *testing.T) {
+	setInventory(t, inventoryDevices)
+
+	tests := []struct {
+		name    string
+		query   string
+		wantIds string
+	}{
+		{"Normal case: Every device", "", "cpu1,cpu2,mem1,nic1"},
+		{"Normal case: Type", "?type=CPU", "cpu1,cpu2"},
+		{"Normal case: Repeated type", "?type=memory&type=networkInterface", "mem1,nic1"},
+		{"Normal case: State", "?state=Enabled", "cpu1,mem1"},
+		{"Normal case: Type and health", "?type=CPU&health=Critical", "cpu2"},
+		{"Normal case: No match", "?health=Unknown", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodGet, "/cdim/api/v1/devices"+tt.query, nil)
+
+			GetDevices(ginContext)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("GetDevices() status = %d, want %d", w.Code, http.StatusOK)
+			}
+			var got inventoryList
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("GetDevices() body is not JSON: %v", err)
+			}
+			if deviceIds(got.Devices) != tt.wantIds || got.Count != len(got.Devices) || got.InfoTimestamp != "2025-01-01T00:00:00Z" {
+				t.Errorf("GetDevices() = %s, want devices %q", w.Body.String(), tt.wantIds)
+			}
+			if w.Header().Get("ETag") == "" {
+				t.Errorf("GetDevices() has no ETag")
+			}
+		})
+	}
+}
+
+func TestGetDevice(t *testing.T) {
+	setInventory(t, inventoryDevices)
+
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{"Normal case: Device in the snapshot", "mem1", http.StatusOK},
+		{"Error case: Device not in the snapshot", "mem9", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodGet, "/cdim/api/v1/devices/"+tt.id, nil)
+			ginContext.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			GetDevice(ginContext)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GetDevice() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Code != http.StatusOK {
+				if !strings.Contains(w.Body.String(), `"0036"`) {
+					t.Errorf("GetDevice() body = %s, want code 0036", w.Body.String())
+				}
+				return
+			}
+			var got inventoryDevice
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("GetDevice() body is not JSON: %v", err)
+			}
+			if got.Device.ID != tt.id || got.Device.Status.Health != "Warning" {
+				t.Errorf("GetDevice() = %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetDevices_ifNoneMatch(t *testing.T) {
+	setInventory(t, inventoryDevices)
+
+	get := func(query string, ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ginContext, _ := gin.CreateTestContext(w)
+		ginContext.Request = httptest.NewRequest(http.MethodGet, "/cdim/api/v1/devices"+query, nil)
+		if ifNoneMatch != "" {
+			ginContext.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		GetDevices(ginContext)
+		return w
+	}
+	etag := get("", "").Header().Get("ETag")
+
+	tests := []struct {
+		name        string
+		query       string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{"Normal case: Same ETag", "", etag, http.StatusNotModified},
+		{"Normal case: ETag in a list", "", `"other", ` + etag, http.StatusNotModified},
+		{"Normal case: Weak ETag", "", "W/" + etag, http.StatusNotModified},
+		{"Normal case: Any ETag", "", "*", http.StatusNotModified},
+		{"Normal case: Other ETag", "", `"other"`, http.StatusOK},
+		{"Normal case: Other filter", "?type=CPU", etag, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(tt.query, tt.ifNoneMatch)
+			if w.Code != tt.wantStatus {
+				t.Errorf("GetDevices() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Code == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("GetDevices() 304 body = %s, want none", w.Body.String())
+			}
+		})
+	}
+
+	// A new snapshot changes the ETag
+	setInventory(t, `[{"deviceID": "cpu1", "type": "CPU"}]`)
+	if w := get("", etag); w.Code != http.StatusOK {
+		t.Errorf("GetDevices() after a new snapshot status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
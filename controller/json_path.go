@@ -0,0 +1,182 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath expression.
+//
+// The supported subset is the root ($), member access by name (.name and ['name']),
+// array index ([0], negative from the end) and wildcard (.* and [*]).
+type jsonPath struct {
+	expr     string
+	segments []jsonPathSegment
+}
+
+type jsonPathSegment struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// compileJsonPath parses a JSONPath expression
+func compileJsonPath(expr string) (*jsonPath, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("JSONPath must start with $: %s", expr)
+	}
+
+	path := &jsonPath{expr: expr}
+	rest := expr[1:]
+	for rest != "" {
+		var segment jsonPathSegment
+		var err error
+
+		switch rest[0] {
+		case '.':
+			segment, rest, err = parseDotSegment(rest[1:])
+		case '[':
+			segment, rest, err = parseBracketSegment(rest[1:])
+		default:
+			err = fmt.Errorf("unexpected character %q", rest[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %s: %w", expr, err)
+		}
+		path.segments = append(path.segments, segment)
+	}
+
+	return path, nil
+}
+
+// Parse the segment after a dot: a member name or *
+func parseDotSegment(s string) (jsonPathSegment, string, error) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		end = len(s)
+	}
+	name := s[:end]
+
+	switch name {
+	case "":
+		return jsonPathSegment{}, "", fmt.Errorf("empty member name")
+	case "*":
+		return jsonPathSegment{wildcard: true}, s[end:], nil
+	}
+	return jsonPathSegment{name: name}, s[end:], nil
+}
+
+// Parse the segment after an opening bracket: a quoted member name, an index or *
+func parseBracketSegment(s string) (jsonPathSegment, string, error) {
+	if s != "" && (s[0] == '\'' || s[0] == '"') {
+		quote := s[0]
+		end := strings.IndexByte(s[1:], quote)
+		if end < 0 || len(s) < end+3 || s[end+2] != ']' {
+			return jsonPathSegment{}, "", fmt.Errorf("unterminated member name")
+		}
+		return jsonPathSegment{name: s[1 : end+1]}, s[end+3:], nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return jsonPathSegment{}, "", fmt.Errorf("missing ]")
+	}
+	content := strings.TrimSpace(s[:end])
+	if content == "*" {
+		return jsonPathSegment{wildcard: true}, s[end+1:], nil
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return jsonPathSegment{}, "", fmt.Errorf("invalid index %q", content)
+	}
+	return jsonPathSegment{index: index, isIndex: true}, s[end+1:], nil
+}
+
+// definite reports whether the path selects at most one value, that is it has no wildcard
+func (p *jsonPath) definite() bool {
+	for _, segment := range p.segments {
+		if segment.wildcard {
+			return false
+		}
+	}
+	return true
+}
+
+// find returns every value selected by the path in the document
+func (p *jsonPath) find(document any) []any {
+	current := []any{document}
+
+	for _, segment := range p.segments {
+		next := make([]any, 0, len(current))
+		for _, value := range current {
+			next = append(next, segment.apply(value)...)
+		}
+		current = next
+	}
+
+	return current
+}
+
+// lookup returns the value selected by a definite path, or false when nothing is selected
+func (p *jsonPath) lookup(document any) (any, bool) {
+	values := p.find(document)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+// Return the values the segment selects in value
+func (s jsonPathSegment) apply(value any) []any {
+	switch typed := value.(type) {
+	case map[string]any:
+		if s.wildcard {
+			// Members are selected in the order of their names, so that the result is stable
+			values := make([]any, 0, len(typed))
+			for _, name := range slices.Sorted(maps.Keys(typed)) {
+				values = append(values, typed[name])
+			}
+			return values
+		}
+		if v, ok := typed[s.name]; ok && !s.isIndex {
+			return []any{v}
+		}
+	case []any:
+		if s.wildcard {
+			return typed
+		}
+		if s.isIndex {
+			index := s.index
+			if index < 0 {
+				index += len(typed)
+			}
+			if index >= 0 && index < len(typed) {
+				return []any{typed[index]}
+			}
+		}
+	}
+	return nil
+}
+
+// String returns the expression the path was compiled from
+func (p *jsonPath) String() string {
+	return p.expr
+}
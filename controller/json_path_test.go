@@ -0,0 +1,67 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_jsonPath_find(t *testing.T) {
+	document := map[string]any{
+		"deviceID": "dev1",
+		"status":   map[string]any{"state": "Enabled", "health": "OK"},
+		"links": []any{
+			map[string]any{"type": "memory", "deviceID": "dev2"},
+			map[string]any{"type": "CPU", "deviceID": "dev3"},
+		},
+		"odd.name": "x",
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    []any
+		wantErr bool
+	}{
+		{"Normal case: Root", "$", []any{document}, false},
+		{"Normal case: Nested member", "$.status.state", []any{"Enabled"}, false},
+		{"Normal case: Quoted member", "$['odd.name']", []any{"x"}, false},
+		{"Normal case: Index", "$.links[1].deviceID", []any{"dev3"}, false},
+		{"Normal case: Negative index", "$.links[-1].type", []any{"CPU"}, false},
+		{"Normal case: Wildcard on an array", "$.links[*].deviceID", []any{"dev2", "dev3"}, false},
+		{"Normal case: Wildcard on an object", "$.status.*", []any{"OK", "Enabled"}, false},
+		{"Normal case: Missing member", "$.attribute.vendor", []any{}, false},
+		{"Normal case: Index out of range", "$.links[5]", []any{}, false},
+		{"Error case: Does not start with $", "status.state", nil, true},
+		{"Error case: Empty member name", "$..state", nil, true},
+		{"Error case: Invalid index", "$.links[a]", nil, true},
+		{"Error case: Unterminated bracket", "$.links[0", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := compileJsonPath(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileJsonPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := path.find(document); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
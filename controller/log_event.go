@@ -0,0 +1,237 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+const (
+	defaultMaxPayloadSize int = 4096
+	maxPayloadSizeLimit   int = 10485760
+	redactedValue             = "[REDACTED]"
+)
+
+// Fields redacted from the logged payloads when logging_configs/redact_fields is omitted
+var defaultRedactFields = []string{"password", "token", "secret", "authorization"}
+
+// Payloads of the alerts are logged in the events of their delivery.
+//
+//   - level:            level of the application log, debug, info, warn or error.
+//   - trail_level:      level of the audit trail. At warn, only the 4xx and 5xx responses are recorded, at error only the 5xx.
+//     The levels are applied when their values change, they can be changed in between through the admin API.
+//   - max_payload_size: bytes of a payload that are logged, the rest is cut off. 0 leaves the payloads out.
+//   - redact_fields:    keys of the JSON payloads whose values are replaced with [REDACTED], at any depth, ignoring case.
+type yamlLoggingConfig struct {
+	Level          string   `yaml:"level"`
+	TrailLevel     string   `yaml:"trail_level"`
+	MaxPayloadSize *int     `yaml:"max_payload_size"`
+	RedactFields   []string `yaml:"redact_fields"`
+}
+
+// The logging settings of the latest sync, applied to the events of every sync
+var loggingSettings atomic.Pointer[yamlLoggingConfig]
+
+// logEvent is a structured log line, written as a JSON object so that log pipelines can query its fields
+type logEvent struct {
+	Event     string `json:"event"`
+	RequestId string `json:"requestId,omitempty"`
+	// collect, forward, alert or sync
+	Phase      string `json:"phase"`
+	AlertName  string `json:"alertName,omitempty"`
+	Sink       string `json:"sink,omitempty"`
+	Target     string `json:"target,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Succeeded  bool   `json:"succeeded"`
+	Error      string `json:"error,omitempty"`
+	Code       string `json:"code,omitempty"`
+	// Device counts, by kind
+	Devices    map[string]int `json:"devices,omitempty"`
+	DurationMs int64          `json:"durationMs"`
+	// Alerts of a sync and how many of them failed
+	Alerts       int `json:"alerts,omitempty"`
+	FailedAlerts int `json:"failedAlerts,omitempty"`
+
+	Payload          string `json:"payload,omitempty"`
+	PayloadBytes     int    `json:"payloadBytes,omitempty"`
+	PayloadTruncated bool   `json:"payloadTruncated,omitempty"`
+}
+
+// Check the logging_configs settings and fill in the defaults
+func validLoggingConfig(settings *yamlLoggingConfig) error {
+	var err error
+	settings.Level, err = validConfigLogLevel("logging_configs/level", settings.Level)
+	if err != nil {
+		return err
+	}
+	settings.TrailLevel, err = validConfigLogLevel("logging_configs/trail_level", settings.TrailLevel)
+	if err != nil {
+		return err
+	}
+
+	settings.MaxPayloadSize, err = validConfigCount("logging_configs/max_payload_size", settings.MaxPayloadSize, defaultMaxPayloadSize, maxPayloadSizeLimit)
+	if err != nil {
+		return err
+	}
+
+	if settings.RedactFields == nil {
+		settings.RedactFields = defaultRedactFields
+	}
+	for i, field := range settings.RedactFields {
+		if field == "" {
+			return errValueBlank.New(fmt.Sprintf("logging_configs/redact_fields[%d]", i))
+		}
+	}
+
+	return nil
+}
+
+// applyLoggingConfig makes the logging settings of a sync apply to the following events,
+// and sets the log levels when they have changed
+func applyLoggingConfig(settings *yamlLoggingConfig) {
+	loggingSettings.Store(settings)
+	applyConfigLogLevels(settings)
+}
+
+// Return the logging settings that apply, the defaults before the first sync
+func currentLoggingConfig() *yamlLoggingConfig {
+	if settings := loggingSettings.Load(); settings != nil {
+		return settings
+	}
+	maxPayloadSize := defaultMaxPayloadSize
+	return &yamlLoggingConfig{MaxPayloadSize: &maxPayloadSize, RedactFields: defaultRedactFields}
+}
+
+// deliveryEvent returns the event of a forward or an alert from its outcome
+func deliveryEvent(phase string, target string, outcome deliveryOutcome) logEvent {
+	return logEvent{
+		Event:      phase + ".delivered",
+		Phase:      phase,
+		AlertName:  outcome.AlertName,
+		Sink:       outcome.Sink,
+		Target:     redactUrl(target),
+		StatusCode: outcome.StatusCode,
+		Succeeded:  outcome.Succeeded,
+		Error:      outcome.Error,
+		DurationMs: outcome.DurationMs,
+	}
+}
+
+// withPayload sets the payload of the event, redacted and truncated according to the settings
+func (e logEvent) withPayload(settings *yamlLoggingConfig, payload []byte) logEvent {
+	e.PayloadBytes = len(payload)
+	if *settings.MaxPayloadSize == 0 || len(payload) == 0 {
+		return e
+	}
+
+	logged := string(redactPayload(payload, settings.RedactFields))
+	if len(logged) > *settings.MaxPayloadSize {
+		// Cut at a rune boundary so that the payload stays valid UTF-8
+		cut := *settings.MaxPayloadSize
+		for cut > 0 && !utf8.RuneStart(logged[cut]) {
+			cut--
+		}
+		logged = logged[:cut]
+		e.PayloadTruncated = true
+	}
+	e.Payload = logged
+	return e
+}
+
+// withError sets the error of the event, with its code when it is an ExpError
+func (e logEvent) withError(err error) logEvent {
+	if err == nil {
+		return e
+	}
+	e.Error = err.Error()
+	var expErr *ExpError
+	if errors.As(err, &expErr) {
+		e.Error = expErr.Message
+		e.Code = expErr.Code
+	}
+	return e
+}
+
+// redactPayload replaces the values of the fields in a JSON payload.
+// A payload that is not JSON is returned as is.
+func redactPayload(payload []byte, fields []string) []byte {
+	if len(fields) == 0 {
+		return payload
+	}
+
+	var value any
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return payload
+	}
+	redacted, err := json.Marshal(redactValue(value, fields))
+	if err != nil {
+		return payload
+	}
+	return redacted
+}
+
+func redactValue(value any, fields []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if containsFold(fields, key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactValue(child, fields)
+			}
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(child, fields)
+		}
+	case string:
+		// The entries of an Alertmanager alert are JSON in a string
+		trimmed := strings.TrimSpace(v)
+		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			return string(redactPayload([]byte(v), fields))
+		}
+	}
+	return value
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// event writes the event as a JSON object, as an error when it did not succeed.
+// The request ID is a field of the event instead of a prefix.
+func (l requestLogger) event(e logEvent) {
+	e.RequestId = l.id
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Error(l.prefix(err.Error()))
+		return
+	}
+	if e.Succeeded {
+		log.Info(string(line))
+	} else {
+		log.Error(string(line))
+	}
+}
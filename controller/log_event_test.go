@@ -0,0 +1,122 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_validLoggingConfig(t *testing.T) {
+	size := func(v int) *int { return &v }
+
+	tests := []struct {
+		name     string
+		settings yamlLoggingConfig
+		wantErr  bool
+	}{
+		{"Normal case: Defaults", yamlLoggingConfig{}, false},
+		{"Normal case: Payloads are left out", yamlLoggingConfig{MaxPayloadSize: size(0), RedactFields: []string{}}, false},
+		{"Error case: Negative size", yamlLoggingConfig{MaxPayloadSize: size(-1)}, true},
+		{"Error case: Blank field", yamlLoggingConfig{RedactFields: []string{"token", ""}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validLoggingConfig(&tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validLoggingConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (tt.settings.MaxPayloadSize == nil || tt.settings.RedactFields == nil) {
+				t.Errorf("validLoggingConfig() did not fill in the defaults: %+v", tt.settings)
+			}
+		})
+	}
+}
+
+func Test_logEvent_withPayload(t *testing.T) {
+	size := func(v int) *int { return &v }
+	redact := []string{"password", "Token"}
+
+	tests := []struct {
+		name          string
+		settings      yamlLoggingConfig
+		payload       string
+		wantPayload   string
+		wantTruncated bool
+	}{
+		{"Normal case: Fields are redacted at any depth, ignoring case",
+			yamlLoggingConfig{MaxPayloadSize: size(1024), RedactFields: redact},
+			`{"user":"a","PASSWORD":"p","list":[{"token":"t"}]}`, `{"PASSWORD":"[REDACTED]","list":[{"token":"[REDACTED]"}],"user":"a"}`, false},
+		{"Normal case: Fields are redacted in the JSON of an annotation",
+			yamlLoggingConfig{MaxPayloadSize: size(1024), RedactFields: redact},
+			`[{"annotations":{"deviceList":"[{\"password\":\"p\"}]"}}]`, `[{"annotations":{"deviceList":"[{\"password\":\"[REDACTED]\"}]"}}]`, false},
+		{"Normal case: Payload that is not JSON is kept",
+			yamlLoggingConfig{MaxPayloadSize: size(1024), RedactFields: redact},
+			`password=p`, `password=p`, false},
+		{"Normal case: Payload is truncated at a rune boundary",
+			yamlLoggingConfig{MaxPayloadSize: size(4), RedactFields: redact},
+			`"ééé"`, `"é`, true},
+		{"Normal case: Payload is left out",
+			yamlLoggingConfig{MaxPayloadSize: size(0), RedactFields: redact},
+			`{"user":"a"}`, ``, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := logEvent{}.withPayload(&tt.settings, []byte(tt.payload))
+			if got.Payload != tt.wantPayload || got.PayloadTruncated != tt.wantTruncated {
+				t.Errorf("withPayload() = %q, truncated %v, want %q, %v", got.Payload, got.PayloadTruncated, tt.wantPayload, tt.wantTruncated)
+			}
+			if got.PayloadBytes != len(tt.payload) {
+				t.Errorf("withPayload() bytes = %d, want %d", got.PayloadBytes, len(tt.payload))
+			}
+		})
+	}
+}
+
+func Test_logEvent_withError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantError string
+		wantCode  string
+	}{
+		{"Normal case: ExpError", errCollectTarget.New(), "Collect target failure.", "0007"},
+		{"Normal case: Other error", errors.New("failure"), "failure", ""},
+		{"Normal case: No error", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := logEvent{}.withError(tt.err)
+			if got.Error != tt.wantError || got.Code != tt.wantCode {
+				t.Errorf("withError() = %q, %q, want %q, %q", got.Error, got.Code, tt.wantError, tt.wantCode)
+			}
+		})
+	}
+}
+
+func Test_syncResult_event(t *testing.T) {
+	result := syncResult{CollectedDevices: 3, ForwardedDevices: 2, AbnormalDevices: 1}
+	if got := result.event(); got.Event != "sync.planned" || !got.Succeeded || got.Devices["forwarded"] != 2 {
+		t.Errorf("event() = %+v, want a planned sync", got)
+	}
+
+	result.Forward = &deliveryOutcome{StatusCode: 201, Succeeded: true}
+	result.Alerts = []deliveryOutcome{{Succeeded: true}, {Succeeded: false}}
+	result.DurationMs = 12
+	got := result.event()
+	if got.Event != "sync.completed" || got.Succeeded || got.Alerts != 2 || got.FailedAlerts != 1 || got.DurationMs != 12 {
+		t.Errorf("event() = %+v, want a completed sync with a failed alert", got)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Log levels, from the most to the least verbose. The zero value is the default, info.
+const (
+	levelDebug int32 = iota - 1
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+const defaultLogLevel string = "info"
+
+// Runtime levels of the application log and of the audit trail.
+// They are set by logging_configs when its values change, and by the admin API.
+var appLogLevel, trailLogLevel atomic.Int32
+
+// Values of logging_configs last applied, so that a level set through the API stays until the settings change
+var configLogLevels struct {
+	sync.Mutex
+	application string
+	trail       string
+}
+
+// logLevels is the body of the log level API, a level is left unchanged when it is omitted from a request
+type logLevels struct {
+	Application string `json:"application,omitempty"`
+	Trail       string `json:"trail,omitempty"`
+}
+
+// leveledLogger writes to the application logger the lines of the runtime level and above
+type leveledLogger struct{}
+
+// The application logger of the controller
+var log leveledLogger
+
+func (leveledLogger) Debug(msg string) {
+	if appLogLevel.Load() <= levelDebug {
+		baseLog.Debug(msg)
+	}
+}
+
+func (leveledLogger) Info(msg string) {
+	if appLogLevel.Load() <= levelInfo {
+		baseLog.Info(msg)
+	}
+}
+
+func (leveledLogger) Warn(msg string) {
+	if appLogLevel.Load() <= levelWarn {
+		baseLog.Warn(msg)
+	}
+}
+
+// Error writes an error line, the optional flag is passed to the application logger as is
+func (leveledLogger) Error(msg string, stack ...bool) {
+	if appLogLevel.Load() <= levelError {
+		baseLog.Error(msg, stack...)
+	}
+}
+
+// TrailRecordsRequests reports whether the audit trail records the start of every request, at the info level and below.
+// Above it, a request is recorded once it has been answered, if its response is recorded.
+func TrailRecordsRequests() bool {
+	return trailLogLevel.Load() <= levelInfo
+}
+
+// TrailRecordsResponse reports whether the audit trail records a response with the status.
+// Every response is recorded at the info level and below, the 4xx and 5xx at warn, the 5xx at error.
+func TrailRecordsResponse(status int) bool {
+	switch trailLogLevel.Load() {
+	case levelWarn:
+		return status >= http.StatusBadRequest
+	case levelError:
+		return status >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// Check a level of logging_configs, the default level is set when it is omitted
+func validConfigLogLevel(targetName string, level string) (string, error) {
+	if level == "" {
+		return defaultLogLevel, nil
+	}
+	if !slices.Contains(logLevelNames, level) {
+		return "", errLogLevel.New(targetName, strings.Join(logLevelNames, ", "))
+	}
+	return level, nil
+}
+
+// Return the level of the name, which has been checked
+func parseLogLevel(name string) int32 {
+	return int32(slices.Index(logLevelNames, name)) + levelDebug
+}
+
+// Return the name of the level
+func logLevelName(level int32) string {
+	return logLevelNames[level-levelDebug]
+}
+
+// Return the current levels
+func currentLogLevels() logLevels {
+	return logLevels{Application: logLevelName(appLogLevel.Load()), Trail: logLevelName(trailLogLevel.Load())}
+}
+
+// applyConfigLogLevels sets the levels of logging_configs when they differ from those last applied.
+// The change is recorded in the audit trail.
+func applyConfigLogLevels(settings *yamlLoggingConfig) {
+	configLogLevels.Lock()
+	defer configLogLevels.Unlock()
+
+	if settings.Level == configLogLevels.application && settings.TrailLevel == configLogLevels.trail {
+		return
+	}
+	configLogLevels.application = settings.Level
+	configLogLevels.trail = settings.TrailLevel
+
+	setLogLevels(logLevels{Application: settings.Level, Trail: settings.TrailLevel}, "-", yamlFilePath, Caller{User: "-", Source: callerSettings})
+}
+
+// setLogLevels sets the levels that are not empty and records the change, and who made it, in the audit trail
+func setLogLevels(levels logLevels, method string, path string, caller Caller) logLevels {
+	before := currentLogLevels()
+	if levels.Application != "" {
+		appLogLevel.Store(parseLogLevel(levels.Application))
+	}
+	if levels.Trail != "" {
+		trailLogLevel.Store(parseLogLevel(levels.Trail))
+	}
+	after := currentLogLevels()
+
+	if before != after {
+		trailLog.TrailReq(method, path, caller.User, fmt.Sprintf("log level changed. application: %s -> %s, trail: %s -> %s, %s",
+			before.Application, after.Application, before.Trail, after.Trail, caller))
+	}
+	return after
+}
+
+// StartLogging applies the levels of logging_configs and the caller identification of audit_configs,
+// and reads the run history of history_configs. They are applied again whenever the settings are loaded for a sync.
+// The defaults are kept when the settings cannot be loaded.
+func StartLogging() {
+	settings := yamlContent{}
+	if err := loadConfig(yamlFilePath, &settings); err != nil {
+		log.Error(fmt.Sprintf("The logging settings are not applied. %s", err.Error()))
+		return
+	}
+	applyLoggingConfig(&settings.LoggingConfigs)
+	applyAuditConfig(&settings.AuditConfigs)
+	applyHistoryConfig(&settings.HistoryConfigs)
+}
+
+// GetLogLevel returns the levels of the application log and of the audit trail.
+//
+// Response Codes:
+//   - 200 OK: Returned with the levels.
+func GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, currentLogLevels())
+}
+
+// SetLogLevel changes the levels of the application log and of the audit trail, without restarting.
+// The body sets application, trail or both to debug, info, warn or error.
+// A level set this way stays until it is set again, or until its value in logging_configs changes.
+//
+// Response Codes:
+//   - 200 OK: Returned with the levels after the change.
+//   - 400 Bad Request: Returned when the body is not valid.
+func SetLogLevel(c *gin.Context) {
+	logger := logFor(c.Request.Context())
+
+	levels, err := parseLogLevels(c)
+	if err != nil {
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setLogLevels(levels, c.Request.Method, c.Request.URL.Path, CallerOf(c)))
+}
+
+// Read and check the body of a log level change
+func parseLogLevels(c *gin.Context) (logLevels, error) {
+	levels := logLevels{}
+	if c.Request.Body == nil {
+		return levels, errLogLevelRequest.New("Request body", "It is required.")
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return levels, errLogLevelRequest.Wrap(err, "Request body", "Failed to read it.")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&levels); err != nil {
+		return levels, errLogLevelRequest.Wrap(err, "Request body", err)
+	}
+	if levels.Application == "" && levels.Trail == "" {
+		return levels, errLogLevelRequest.New("Request body", "At least one of application and trail is required.")
+	}
+
+	for name, level := range map[string]string{"application": levels.Application, "trail": levels.Trail} {
+		if level != "" && !slices.Contains(logLevelNames, level) {
+			return levels, errLogLevelRequest.New(name, fmt.Sprintf("It must be one of %s.", strings.Join(logLevelNames, ", ")))
+		}
+	}
+
+	return levels, nil
+}
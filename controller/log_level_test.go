@@ -0,0 +1,141 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Restore the default levels after the test
+func resetLogLevels(t *testing.T) {
+	t.Cleanup(func() {
+		appLogLevel.Store(levelInfo)
+		trailLogLevel.Store(levelInfo)
+		configLogLevels.Lock()
+		configLogLevels.application, configLogLevels.trail = "", ""
+		configLogLevels.Unlock()
+	})
+}
+
+func Test_SetLogLevel(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       logLevels
+	}{
+		{"Normal case: Both levels", `{"application": "debug", "trail": "warn"}`, http.StatusOK, logLevels{"debug", "warn"}},
+		{"Normal case: Application level only", `{"application": "error"}`, http.StatusOK, logLevels{"error", "info"}},
+		{"Error case: Unknown level", `{"application": "verbose"}`, http.StatusBadRequest, logLevels{"info", "info"}},
+		{"Error case: Neither level", `{}`, http.StatusBadRequest, logLevels{"info", "info"}},
+		{"Error case: Unknown field", `{"app": "debug"}`, http.StatusBadRequest, logLevels{"info", "info"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetLogLevels(t)
+
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodPut, "/cdim/api/v1/admin/loglevel", strings.NewReader(tt.body))
+
+			SetLogLevel(ginContext)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("SetLogLevel() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := currentLogLevels(); got != tt.want {
+				t.Errorf("SetLogLevel() levels = %+v, want %+v", got, tt.want)
+			}
+			if w.Code == http.StatusOK {
+				var got logLevels
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got != tt.want {
+					t.Errorf("SetLogLevel() body = %s, want %+v", w.Body.String(), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_applyConfigLogLevels(t *testing.T) {
+	resetLogLevels(t)
+
+	applyConfigLogLevels(&yamlLoggingConfig{Level: "warn", TrailLevel: "info"})
+	if got := currentLogLevels(); got != (logLevels{"warn", "info"}) {
+		t.Fatalf("applyConfigLogLevels() levels = %+v, want the levels of the settings", got)
+	}
+
+	// A level set through the API stays while the settings are unchanged
+	setLogLevels(logLevels{Application: "debug"}, http.MethodPut, "/cdim/api/v1/admin/loglevel", Caller{User: "admin", Source: callerToken})
+	applyConfigLogLevels(&yamlLoggingConfig{Level: "warn", TrailLevel: "info"})
+	if got := currentLogLevels(); got != (logLevels{"debug", "info"}) {
+		t.Errorf("applyConfigLogLevels() levels = %+v, want the level set through the API", got)
+	}
+
+	applyConfigLogLevels(&yamlLoggingConfig{Level: "error", TrailLevel: "info"})
+	if got := currentLogLevels(); got != (logLevels{"error", "info"}) {
+		t.Errorf("applyConfigLogLevels() levels = %+v, want the changed level of the settings", got)
+	}
+}
+
+func Test_TrailRecordsResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  int32
+		status int
+		want   bool
+	}{
+		{"Normal case: Info records every response", levelInfo, http.StatusOK, true},
+		{"Normal case: Warn records client errors", levelWarn, http.StatusNotFound, true},
+		{"Normal case: Warn does not record successes", levelWarn, http.StatusOK, false},
+		{"Normal case: Error records server errors", levelError, http.StatusBadGateway, true},
+		{"Normal case: Error does not record client errors", levelError, http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetLogLevels(t)
+			trailLogLevel.Store(tt.level)
+			if got := TrailRecordsResponse(tt.status); got != tt.want {
+				t.Errorf("TrailRecordsResponse(%d) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validConfigLogLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		want    string
+		wantErr bool
+	}{
+		{"Normal case: Default level", "", "info", false},
+		{"Normal case: Level", "debug", "debug", false},
+		{"Error case: Unknown level", "trace", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validConfigLogLevel("logging_configs/level", tt.level)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("validConfigLogLevel() = %s, %v, want %s, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
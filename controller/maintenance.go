@@ -0,0 +1,450 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recurrences of a maintenance window
+const (
+	recurrenceNone   string = "none"
+	recurrenceDaily  string = "daily"
+	recurrenceWeekly string = "weekly"
+)
+
+// Sources of a maintenance window
+const (
+	maintenanceSourceConfig string = "config"
+	maintenanceSourceApi    string = "api"
+)
+
+// During a maintenance window, the devices matching its selector are not alerted on: they are left out of
+// abnormalStatusDeviceList, invalidDeviceList and, for the entries with a deviceID, incompleteDeviceList.
+// They are still forwarded to configuration-manager.
+//
+// start and end are RFC 3339 date-times delimiting the first occurrence.
+// With a daily or weekly recurrence, the window repeats with the same duration until until, if given.
+type yamlMaintenanceConfig struct {
+	Windows []yamlMaintenanceWindow `yaml:"windows"`
+
+	// Windows converted from the settings, set by validMaintenanceConfig
+	windows []maintenanceWindow
+}
+
+type yamlMaintenanceWindow struct {
+	Name       string             `yaml:"name"`
+	Start      string             `yaml:"start"`
+	End        string             `yaml:"end"`
+	Recurrence string             `yaml:"recurrence"`
+	Until      string             `yaml:"until"`
+	Reason     string             `yaml:"reason"`
+	Selector   yamlDeviceSelector `yaml:"selector"`
+}
+
+// maintenanceWindow is a maintenance window defined in the settings or created through the API
+type maintenanceWindow struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Start      time.Time          `json:"start"`
+	End        time.Time          `json:"end"`
+	Recurrence string             `json:"recurrence"`
+	Until      *time.Time         `json:"until,omitempty"`
+	Reason     string             `json:"reason"`
+	Selector   yamlDeviceSelector `json:"selector"`
+	Source     string             `json:"source"`
+}
+
+// suppressedDevice is a device that was not alerted on because of a maintenance window
+type suppressedDevice struct {
+	DeviceID string `json:"deviceID"`
+	// Name of the alert the device was left out of
+	Alert    string `json:"alert"`
+	WindowID string `json:"windowID"`
+	Window   string `json:"window"`
+	Reason   string `json:"reason"`
+}
+
+// Maintenance windows created through the API. They are kept in memory.
+var apiMaintenanceWindows struct {
+	sync.RWMutex
+	windows []maintenanceWindow
+}
+
+// Check the maintenance_configs settings and convert the windows
+func validMaintenanceConfig(settings *yamlMaintenanceConfig, labels map[string][]string) error {
+	settings.windows = make([]maintenanceWindow, 0, len(settings.Windows))
+
+	for i, window := range settings.Windows {
+		targetName := fmt.Sprintf("maintenance_configs/windows[%d]", i)
+
+		converted, err := newConfigMaintenanceWindow(window)
+		if err == nil {
+			err = validMaintenanceWindow(&converted, labels)
+		}
+		if err != nil {
+			return errMaintenanceConfig.Wrap(err, targetName, err)
+		}
+		if slices.ContainsFunc(settings.windows, func(w maintenanceWindow) bool { return w.ID == converted.ID }) {
+			return errMaintenanceConfig.New(targetName, fmt.Sprintf("name %s is not unique.", window.Name))
+		}
+		settings.windows = append(settings.windows, converted)
+	}
+
+	return nil
+}
+
+// Convert a window of the settings
+func newConfigMaintenanceWindow(window yamlMaintenanceWindow) (maintenanceWindow, error) {
+	if window.Name == "" {
+		return maintenanceWindow{}, fmt.Errorf("name is required.")
+	}
+
+	converted := maintenanceWindow{
+		ID:         maintenanceSourceConfig + "-" + window.Name,
+		Name:       window.Name,
+		Recurrence: window.Recurrence,
+		Reason:     window.Reason,
+		Selector:   window.Selector,
+		Source:     maintenanceSourceConfig,
+	}
+
+	var err error
+	if converted.Start, err = time.Parse(time.RFC3339, window.Start); err != nil {
+		return maintenanceWindow{}, fmt.Errorf("start is not an RFC 3339 date-time.")
+	}
+	if converted.End, err = time.Parse(time.RFC3339, window.End); err != nil {
+		return maintenanceWindow{}, fmt.Errorf("end is not an RFC 3339 date-time.")
+	}
+	if window.Until != "" {
+		until, err := time.Parse(time.RFC3339, window.Until)
+		if err != nil {
+			return maintenanceWindow{}, fmt.Errorf("until is not an RFC 3339 date-time.")
+		}
+		converted.Until = &until
+	}
+
+	return converted, nil
+}
+
+// Check a window. An omitted recurrence is set to none.
+func validMaintenanceWindow(window *maintenanceWindow, labels map[string][]string) error {
+	if window.Recurrence == "" {
+		window.Recurrence = recurrenceNone
+	}
+	if !slices.Contains([]string{recurrenceNone, recurrenceDaily, recurrenceWeekly}, window.Recurrence) {
+		return fmt.Errorf("recurrence must be one of none, daily and weekly.")
+	}
+	// An omitted date-time of a request body is decoded as the zero time
+	if window.Start.IsZero() || window.End.IsZero() {
+		return fmt.Errorf("start and end are required.")
+	}
+	if !window.End.After(window.Start) {
+		return fmt.Errorf("end must be after start.")
+	}
+	if window.Until != nil && window.Until.Before(window.Start) {
+		return fmt.Errorf("until must not be before start.")
+	}
+	if period := recurrencePeriod(window.Recurrence); period > 0 && window.End.Sub(window.Start) > period {
+		return fmt.Errorf("the window must not be longer than its recurrence.")
+	}
+	if _, err := compileDeviceSelector("selector", window.Selector, labels); err != nil {
+		return fmt.Errorf("%s", asExpError(err).Message)
+	}
+	return nil
+}
+
+// Return the interval between the occurrences of a recurring window, or 0 when it does not recur
+func recurrencePeriod(recurrence string) time.Duration {
+	switch recurrence {
+	case recurrenceDaily:
+		return 24 * time.Hour
+	case recurrenceWeekly:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// isActive reports whether an occurrence of the window includes now
+func (w *maintenanceWindow) isActive(now time.Time) bool {
+	if now.Before(w.Start) {
+		return false
+	}
+
+	period := recurrencePeriod(w.Recurrence)
+	if period == 0 {
+		return now.Before(w.End)
+	}
+	if w.Until != nil && now.After(*w.Until) {
+		return false
+	}
+
+	return now.Sub(w.Start)%period < w.End.Sub(w.Start)
+}
+
+// Return the windows of the settings and of the API
+func allMaintenanceWindows(settings *yamlMaintenanceConfig) []maintenanceWindow {
+	apiMaintenanceWindows.RLock()
+	defer apiMaintenanceWindows.RUnlock()
+
+	return slices.Concat(settings.windows, apiMaintenanceWindows.windows)
+}
+
+// maintenanceSuppression suppresses the alerts on the devices matching a maintenance window active during a sync
+type maintenanceSuppression struct {
+	active []activeWindow
+	// Suppressed alerts with the window and its reason
+	suppressed []suppressedDevice
+}
+
+// activeWindow is an active maintenance window with its compiled selector
+type activeWindow struct {
+	window   maintenanceWindow
+	selector *deviceSelector
+}
+
+// newMaintenanceSuppression returns the suppression of the windows of the settings and of the API active at now
+func newMaintenanceSuppression(ctx context.Context, settings *yamlMaintenanceConfig, labels map[string][]string, now time.Time) *maintenanceSuppression {
+	suppression := &maintenanceSuppression{}
+	for _, window := range allMaintenanceWindows(settings) {
+		if !window.isActive(now) {
+			continue
+		}
+		selector, err := compileDeviceSelector("selector", window.Selector, labels)
+		if err != nil {
+			// A window of the API may refer to a label removed from the settings since it was created
+			logFor(ctx).Warn(fmt.Sprintf("maintenance window %s is ignored. %s", window.ID, asExpError(err).Message))
+			continue
+		}
+		suppression.active = append(suppression.active, activeWindow{window, selector})
+	}
+	return suppression
+}
+
+// suppresses reports whether the alert on the device is suppressed by an active window, and records it when it is
+func (s *maintenanceSuppression) suppresses(ctx context.Context, alertName string, device *Device) bool {
+	index := slices.IndexFunc(s.active, func(a activeWindow) bool { return a.selector.matches(device) })
+	if index < 0 {
+		return false
+	}
+
+	window := s.active[index].window
+	logFor(ctx).Info(fmt.Sprintf("The alert %s of device %s is suppressed by maintenance window %s. reason: %s", alertName, device.ID, window.ID, window.Reason))
+	s.suppressed = append(s.suppressed, suppressedDevice{
+		DeviceID: device.ID,
+		Alert:    alertName,
+		WindowID: window.ID,
+		Window:   window.Name,
+		Reason:   window.Reason,
+	})
+	return true
+}
+
+// alertedAbnormal returns the abnormal devices still to alert on
+func (s *maintenanceSuppression) alertedAbnormal(ctx context.Context, abnormal []abnormalDevice) []abnormalDevice {
+	alerted := make([]abnormalDevice, 0, len(abnormal))
+	for i := range abnormal {
+		if !s.suppresses(ctx, abnormalStatusDeviceList, &abnormal[i].Device) {
+			alerted = append(alerted, abnormal[i])
+		}
+	}
+	return alerted
+}
+
+// alertedQuarantined returns the quarantined devices still to alert on
+func (s *maintenanceSuppression) alertedQuarantined(ctx context.Context, quarantined []quarantinedDevice) []quarantinedDevice {
+	alerted := make([]quarantinedDevice, 0, len(quarantined))
+	for i := range quarantined {
+		if !s.suppresses(ctx, invalidDeviceList, &quarantined[i].Device) {
+			alerted = append(alerted, quarantined[i])
+		}
+	}
+	return alerted
+}
+
+// alertedIncomplete returns the entries of incompleteDeviceList still to alert on.
+// Entries without a deviceID cannot be matched and are always alerted on.
+func (s *maintenanceSuppression) alertedIncomplete(ctx context.Context, entries []any) []any {
+	alerted := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		device, ok := entryDevice(entry)
+		if !ok || !s.suppresses(ctx, incompleteDeviceList, &device) {
+			alerted = append(alerted, entry)
+		}
+	}
+	return alerted
+}
+
+// GetMaintenanceWindows returns the maintenance windows of the settings and of the API.
+//
+// Response Codes:
+//   - 200 OK: Returned with the list of windows and whether each is active now.
+//   - 500 Internal Server Error: Returned when the settings cannot be loaded.
+func GetMaintenanceWindows(c *gin.Context) {
+	logger := logFor(c.Request.Context())
+	settings := yamlContent{}
+	err := loadConfig(yamlFilePath, &settings)
+	if err != nil {
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	now := time.Now()
+	windows := make([]gin.H, 0)
+	for _, window := range allMaintenanceWindows(&settings.MaintenanceConfigs) {
+		windows = append(windows, gin.H{"window": window, "active": window.isActive(now)})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": len(windows), "windows": windows})
+}
+
+// CreateMaintenanceWindow creates a maintenance window from the request body.
+// Windows created through the API are kept in memory and are lost when the exporter restarts.
+//
+// Response Codes:
+//   - 201 Created: Returned with the created window.
+//   - 400 Bad Request: Returned when the window is invalid.
+//   - 500 Internal Server Error: Returned when the settings cannot be loaded.
+func CreateMaintenanceWindow(c *gin.Context) {
+	logger := logFor(c.Request.Context())
+	settings := yamlContent{}
+	err := loadConfig(yamlFilePath, &settings)
+	if err != nil {
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	window := maintenanceWindow{}
+	if err := c.ShouldBindJSON(&window); err != nil {
+		err = errMaintenanceRequest.Wrap(err, "Request body", err)
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+	if err := validMaintenanceWindow(&window, settings.DeviceLabels); err != nil {
+		err = errMaintenanceRequest.Wrap(err, "Maintenance window", err)
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	window.ID, err = newMaintenanceWindowId()
+	if err != nil {
+		err = errInternal.Wrap(err, "Failed to generate the maintenance window id.")
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+	window.Source = maintenanceSourceApi
+
+	apiMaintenanceWindows.Lock()
+	apiMaintenanceWindows.windows = append(apiMaintenanceWindows.windows, window)
+	apiMaintenanceWindows.Unlock()
+
+	logger.Info(fmt.Sprintf("maintenance window %s was created. reason: %s", window.ID, window.Reason))
+	c.JSON(http.StatusCreated, window)
+}
+
+// GetMaintenanceWindow returns a maintenance window of the settings or of the API.
+//
+// Response Codes:
+//   - 200 OK: Returned with the window and whether it is active now.
+//   - 404 Not Found: Returned when no window has the id.
+//   - 500 Internal Server Error: Returned when the settings cannot be loaded.
+func GetMaintenanceWindow(c *gin.Context) {
+	logger := logFor(c.Request.Context())
+	settings := yamlContent{}
+	err := loadConfig(yamlFilePath, &settings)
+	if err != nil {
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	id := c.Param("id")
+	windows := allMaintenanceWindows(&settings.MaintenanceConfigs)
+	index := slices.IndexFunc(windows, func(w maintenanceWindow) bool { return w.ID == id })
+	if index < 0 {
+		err := errMaintenanceNotFound.New(id)
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"window": windows[index], "active": windows[index].isActive(time.Now())})
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window created through the API.
+//
+// Response Codes:
+//   - 204 No Content: Returned when the window has been deleted.
+//   - 404 Not Found: Returned when no window has the id.
+//   - 409 Conflict: Returned when the window is defined in the settings, which must be edited instead.
+//   - 500 Internal Server Error: Returned when the settings cannot be loaded.
+func DeleteMaintenanceWindow(c *gin.Context) {
+	logger := logFor(c.Request.Context())
+	settings := yamlContent{}
+	err := loadConfig(yamlFilePath, &settings)
+	if err != nil {
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	id := c.Param("id")
+	if slices.ContainsFunc(settings.MaintenanceConfigs.windows, func(w maintenanceWindow) bool { return w.ID == id }) {
+		err := errMaintenanceConflict.New(id)
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	apiMaintenanceWindows.Lock()
+	index := slices.IndexFunc(apiMaintenanceWindows.windows, func(w maintenanceWindow) bool { return w.ID == id })
+	if index >= 0 {
+		apiMaintenanceWindows.windows = slices.Delete(apiMaintenanceWindows.windows, index, index+1)
+	}
+	apiMaintenanceWindows.Unlock()
+
+	if index < 0 {
+		err := errMaintenanceNotFound.New(id)
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	logger.Info(fmt.Sprintf("maintenance window %s was deleted.", id))
+	c.Status(http.StatusNoContent)
+}
+
+// Generate a random id for a window created through the API
+func newMaintenanceWindowId() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return maintenanceSourceApi + "-" + hex.EncodeToString(b), nil
+}
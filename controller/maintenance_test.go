@@ -0,0 +1,231 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_validMaintenanceConfig(t *testing.T) {
+	labels := map[string][]string{"lab": {"lab1"}}
+	window := func(start, end, recurrence string) yamlMaintenanceWindow {
+		return yamlMaintenanceWindow{
+			Name:       "work",
+			Start:      start,
+			End:        end,
+			Recurrence: recurrence,
+			Selector:   yamlDeviceSelector{Labels: []string{"lab"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		windows []yamlMaintenanceWindow
+		wantErr bool
+	}{
+		{
+			"Normal case: One-off window",
+			[]yamlMaintenanceWindow{window("2025-01-01T00:00:00Z", "2025-01-01T04:00:00Z", "")},
+			false,
+		},
+		{
+			"Normal case: Daily window as long as a day",
+			[]yamlMaintenanceWindow{window("2025-01-01T00:00:00Z", "2025-01-02T00:00:00Z", "daily")},
+			false,
+		},
+		{
+			"Error case: Daily window longer than a day",
+			[]yamlMaintenanceWindow{window("2025-01-01T00:00:00Z", "2025-01-02T00:00:01Z", "daily")},
+			true,
+		},
+		{
+			"Error case: end is not after start",
+			[]yamlMaintenanceWindow{window("2025-01-01T04:00:00Z", "2025-01-01T04:00:00Z", "")},
+			true,
+		},
+		{
+			"Error case: start is not an RFC 3339 date-time",
+			[]yamlMaintenanceWindow{window("2025-01-01 00:00", "2025-01-01T04:00:00Z", "")},
+			true,
+		},
+		{
+			"Error case: recurrence is not supported",
+			[]yamlMaintenanceWindow{window("2025-01-01T00:00:00Z", "2025-01-01T04:00:00Z", "monthly")},
+			true,
+		},
+		{
+			"Error case: Names are not unique",
+			[]yamlMaintenanceWindow{
+				window("2025-01-01T00:00:00Z", "2025-01-01T04:00:00Z", ""),
+				window("2025-02-01T00:00:00Z", "2025-02-01T04:00:00Z", ""),
+			},
+			true,
+		},
+		{
+			"Error case: Selector refers to an undefined label",
+			[]yamlMaintenanceWindow{{Name: "work", Start: "2025-01-01T00:00:00Z", End: "2025-01-01T04:00:00Z", Selector: yamlDeviceSelector{Labels: []string{"none"}}}},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := yamlMaintenanceConfig{Windows: tt.windows}
+			err := validMaintenanceConfig(&settings, labels)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validMaintenanceConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && settings.windows[0].Recurrence == "" {
+				t.Errorf("validMaintenanceConfig() recurrence was not defaulted")
+			}
+		})
+	}
+}
+
+func Test_maintenanceWindow_isActive(t *testing.T) {
+	start := time.Date(2025, 1, 6, 1, 0, 0, 0, time.UTC)
+	until := start.Add(14 * 24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		recurrence string
+		until      *time.Time
+		now        time.Time
+		want       bool
+	}{
+		{"Normal case: Before the window", recurrenceNone, nil, start.Add(-time.Second), false},
+		{"Normal case: At the start", recurrenceNone, nil, start, true},
+		{"Normal case: At the end", recurrenceNone, nil, start.Add(4 * time.Hour), false},
+		{"Normal case: Next day without recurrence", recurrenceNone, nil, start.Add(25 * time.Hour), false},
+		{"Normal case: Next day with a daily recurrence", recurrenceDaily, nil, start.Add(25 * time.Hour), true},
+		{"Normal case: Between daily occurrences", recurrenceDaily, nil, start.Add(30 * time.Hour), false},
+		{"Normal case: Next week with a weekly recurrence", recurrenceWeekly, nil, start.Add(7*24*time.Hour + time.Hour), true},
+		{"Normal case: Next day with a weekly recurrence", recurrenceWeekly, nil, start.Add(25 * time.Hour), false},
+		{"Normal case: After until", recurrenceWeekly, &until, start.Add(21*24*time.Hour + time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := maintenanceWindow{Start: start, End: start.Add(4 * time.Hour), Recurrence: tt.recurrence, Until: tt.until}
+			if got := w.isActive(tt.now); got != tt.want {
+				t.Errorf("isActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_maintenanceSuppression(t *testing.T) {
+	now := time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)
+	labels := map[string][]string{"lab": {"lab1", "lab3", "lab4"}}
+	devices := []abnormalDevice{
+		{Device: newDevice(map[string]any{"deviceID": "dev1", "type": "CPU"})},
+		{Device: newDevice(map[string]any{"deviceID": "lab1", "type": "CPU"})},
+		{Device: newDevice(map[string]any{"deviceID": "lab2", "type": "memory"})},
+	}
+	quarantined := []quarantinedDevice{
+		{DeviceID: "lab3", Device: newDevice(map[string]any{"deviceID": "lab3"})},
+		{DeviceID: "dev3", Device: newDevice(map[string]any{"deviceID": "dev3"})},
+	}
+	incomplete := []any{map[string]any{"deviceID": "lab4"}, map[string]any{"deviceID": "dev4"}, "unknown"}
+
+	settings := yamlMaintenanceConfig{Windows: []yamlMaintenanceWindow{
+		{Name: "lab-work", Start: "2025-01-01T00:00:00Z", End: "2025-01-01T04:00:00Z", Reason: "rack work", Selector: yamlDeviceSelector{Labels: []string{"lab"}}},
+		{Name: "past-work", Start: "2024-12-01T00:00:00Z", End: "2024-12-01T04:00:00Z", Selector: yamlDeviceSelector{Types: []string{"memory"}}},
+	}}
+	if err := validMaintenanceConfig(&settings, labels); err != nil {
+		t.Fatalf("validMaintenanceConfig() error = %v", err)
+	}
+
+	suppression := newMaintenanceSuppression(context.Background(), &settings, labels, now)
+	alerted := suppression.alertedAbnormal(context.Background(), devices)
+	if len(alerted) != 2 || alerted[0].Device.ID != "dev1" || alerted[1].Device.ID != "lab2" {
+		t.Errorf("alertedAbnormal() = %v", alerted)
+	}
+	if alerted := suppression.alertedQuarantined(context.Background(), quarantined); len(alerted) != 1 || alerted[0].DeviceID != "dev3" {
+		t.Errorf("alertedQuarantined() = %v", alerted)
+	}
+	if alerted := suppression.alertedIncomplete(context.Background(), incomplete); len(alerted) != 2 || alerted[1] != "unknown" {
+		t.Errorf("alertedIncomplete() = %v", alerted)
+	}
+	want := []suppressedDevice{
+		{DeviceID: "lab1", Alert: abnormalStatusDeviceList, WindowID: "config-lab-work", Window: "lab-work", Reason: "rack work"},
+		{DeviceID: "lab3", Alert: invalidDeviceList, WindowID: "config-lab-work", Window: "lab-work", Reason: "rack work"},
+		{DeviceID: "lab4", Alert: incompleteDeviceList, WindowID: "config-lab-work", Window: "lab-work", Reason: "rack work"},
+	}
+	if !slices.Equal(suppression.suppressed, want) {
+		t.Errorf("suppressed = %v, want %v", suppression.suppressed, want)
+	}
+
+	suppression = newMaintenanceSuppression(context.Background(), &settings, labels, now.Add(24*time.Hour))
+	if alerted := suppression.alertedAbnormal(context.Background(), devices); len(alerted) != 3 || suppression.suppressed != nil {
+		t.Errorf("alertedAbnormal() outside the windows = %v, %v", alerted, suppression.suppressed)
+	}
+}
+
+func TestCreateMaintenanceWindow(t *testing.T) {
+	useSyncConfig(t, "http://localhost/collect", "http://localhost/forward", "http://localhost/alerts")
+	t.Cleanup(func() {
+		apiMaintenanceWindows.Lock()
+		apiMaintenanceWindows.windows = nil
+		apiMaintenanceWindows.Unlock()
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{
+			"Normal case: Daily window",
+			`{"name": "work", "start": "2025-01-01T01:00:00Z", "end": "2025-01-01T03:00:00Z", "recurrence": "daily", "until": "2025-02-01T00:00:00Z", "selector": {"deviceIDs": ["dev1"]}}`,
+			http.StatusCreated,
+		},
+		{
+			"Error case: start is missing",
+			`{"name": "work", "end": "2025-01-01T03:00:00Z", "selector": {"deviceIDs": ["dev1"]}}`,
+			http.StatusBadRequest,
+		},
+		{
+			"Error case: end is missing",
+			`{"name": "work", "start": "2025-01-01T01:00:00Z", "selector": {"deviceIDs": ["dev1"]}}`,
+			http.StatusBadRequest,
+		},
+		{
+			"Error case: until is before start",
+			`{"name": "work", "start": "2025-01-01T01:00:00Z", "end": "2025-01-01T03:00:00Z", "recurrence": "daily", "until": "2024-12-31T00:00:00Z", "selector": {"deviceIDs": ["dev1"]}}`,
+			http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodPost, "/cdim/api/v1/maintenance-windows", strings.NewReader(tt.body))
+			ginContext.Request.Header.Set("Content-Type", "application/json")
+
+			CreateMaintenanceWindow(ginContext)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateMaintenanceWindow() status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
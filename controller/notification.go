@@ -0,0 +1,386 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Kind of the self-monitoring notifications, sent when syncs fail
+const syncFailure string = "syncFailure"
+
+// Kinds of notifications. The device alerts are their own kind.
+var notificationKinds = []string{invalidDeviceList, incompleteDeviceList, abnormalStatusDeviceList, syncFailure}
+
+// Types of notification sinks
+const (
+	sinkAlertmanager string = "alertmanager"
+	sinkWebhook      string = "webhook"
+	sinkSlack        string = "slack"
+	sinkTeams        string = "teams"
+)
+
+var sinkTypes = []string{sinkAlertmanager, sinkWebhook, sinkSlack, sinkTeams}
+
+// Number of device IDs listed in a message of slack and teams
+const maxChatDeviceIds int = 20
+
+// Notifications are sent to alert_config and to every sink receiving their kind.
+//
+//   - alertmanager: the Alertmanager v2 alert format, as alert_config.
+//   - webhook:      the body is the result of the Go template, executed with notificationData. It must be JSON.
+//   - slack:        the Slack incoming webhook format.
+//   - teams:        the Microsoft Teams incoming webhook format (MessageCard).
+//
+// Webhooks of chats limit the size of the messages, slack and teams therefore carry the summary
+// and the IDs of the first maxChatDeviceIds devices, not the devices themselves.
+//
+// kinds lists the kinds a sink receives, every kind when omitted. This also applies to alert_config/kinds.
+type yamlNotificationConfig struct {
+	Sinks []yamlNotificationSink `yaml:"sinks"`
+
+	// Sinks to notify, alert_config first, set by validNotificationConfig
+	sinks []*notificationSink
+}
+
+type yamlNotificationSink struct {
+	Name            string   `yaml:"name"`
+	Type            string   `yaml:"type"`
+	TargetUrl       string   `yaml:"target_url"`
+	TimeOut         *int     `yaml:"timeout"`
+	ContentEncoding string   `yaml:"content_encoding"`
+	Kinds           []string `yaml:"kinds"`
+	Template        string   `yaml:"template"`
+}
+
+// notificationSink is a checked notification sink
+type notificationSink struct {
+	name            string
+	sinkType        string
+	targetUrl       string
+	timeOut         *int
+	contentEncoding string
+	kinds           []string
+	template        *template.Template
+}
+
+// notificationData is the data the webhook templates are executed with
+type notificationData struct {
+	Name      string
+	Kind      string
+	Severity  string
+	Summary   string
+	Count     int
+	Entries   []any
+	Timestamp string
+}
+
+// Data a webhook template is tried with when the settings are checked
+var sampleNotificationData = notificationData{
+	Name:      abnormalStatusDeviceList,
+	Kind:      abnormalStatusDeviceList,
+	Severity:  "critical",
+	Summary:   "1 devices have an abnormal status.",
+	Count:     1,
+	Entries:   []any{map[string]any{"deviceID": "sample", "type": "CPU", "status": map[string]any{"state": "Disabled", "health": "OK"}}},
+	Timestamp: "2025-01-01T00:00:00Z",
+}
+
+// Summaries of the notifications by alert name, %d is replaced with the number of entries
+var notificationSummaries = map[string]string{
+	invalidDeviceList:        "%d devices do not conform to the device schema.",
+	incompleteDeviceList:     "%d devices could not be collected completely.",
+	abnormalStatusDeviceList: "%d devices have an abnormal status.",
+	syncCollectFailure:       "The collection of the devices failed.",
+	syncForwardFailure:       "The forward to configuration-manager failed.",
+	syncConsecutiveFailures:  "Syncs have failed in a row.",
+	syncStalled:              "No sync has succeeded for a while.",
+}
+
+// Check the notification_configs settings and build the sinks, alert_config first
+func validNotificationConfig(settings *yamlNotificationConfig, alertConfig *yamlAlertConfig) error {
+	err := validConfigKinds("alert_config/kinds", alertConfig.Kinds)
+	if err != nil {
+		return err
+	}
+
+	settings.sinks = []*notificationSink{alertConfigSink(alertConfig)}
+
+	for i, sink := range settings.Sinks {
+		targetName := fmt.Sprintf("notification_configs/sinks[%d]", i)
+
+		if sink.Name == "" || slices.ContainsFunc(settings.sinks, func(s *notificationSink) bool { return s.name == sink.Name }) {
+			return errNotificationSink.New(targetName+"/name", "It is required and must be unique.")
+		}
+		if !slices.Contains(sinkTypes, sink.Type) {
+			return errNotificationSink.New(targetName+"/type", fmt.Sprintf("It must be one of %s.", strings.Join(sinkTypes, ", ")))
+		}
+		err = validConfigUrl(targetName+"/target_url", sink.TargetUrl)
+		if err != nil {
+			return err
+		}
+		settings.Sinks[i].TimeOut, err = validConfigTime(targetName+"/timeout", sink.TimeOut)
+		if err != nil {
+			return err
+		}
+		err = validConfigEncoding(targetName+"/content_encoding", sink.ContentEncoding)
+		if err != nil {
+			return err
+		}
+		err = validConfigKinds(targetName+"/kinds", sink.Kinds)
+		if err != nil {
+			return err
+		}
+
+		compiled := &notificationSink{
+			name:            sink.Name,
+			sinkType:        sink.Type,
+			targetUrl:       sink.TargetUrl,
+			timeOut:         settings.Sinks[i].TimeOut,
+			contentEncoding: sink.ContentEncoding,
+			kinds:           sink.Kinds,
+		}
+		if sink.Type == sinkWebhook {
+			if sink.Template == "" {
+				return errNotificationSink.New(targetName+"/template", "It is required for a webhook.")
+			}
+			compiled.template, err = template.New(sink.Name).Funcs(template.FuncMap{"json": toJsonString}).Parse(sink.Template)
+			if err != nil {
+				return errNotificationSink.Wrap(err, targetName+"/template", err)
+			}
+			// The body is sent as JSON, a template producing something else is rejected early
+			var sample bytes.Buffer
+			if err = compiled.template.Execute(&sample, sampleNotificationData); err != nil {
+				return errNotificationSink.Wrap(err, targetName+"/template", err)
+			}
+			if !json.Valid(sample.Bytes()) {
+				return errNotificationSink.New(targetName+"/template", "It must produce JSON.")
+			}
+		}
+		settings.sinks = append(settings.sinks, compiled)
+	}
+
+	return nil
+}
+
+// Check that every kind is known
+func validConfigKinds(targetName string, kinds []string) error {
+	for _, kind := range kinds {
+		if !slices.Contains(notificationKinds, kind) {
+			return errNotificationSink.New(targetName, fmt.Sprintf("%s is not a notification kind.", kind))
+		}
+	}
+	return nil
+}
+
+// Return the Alertmanager sink of alert_config
+func alertConfigSink(settings *yamlAlertConfig) *notificationSink {
+	return &notificationSink{
+		name:            "alert_config",
+		sinkType:        sinkAlertmanager,
+		targetUrl:       settings.TargetUrl,
+		timeOut:         settings.TimeOut,
+		contentEncoding: settings.ContentEncoding,
+		kinds:           settings.Kinds,
+	}
+}
+
+// accepts reports whether the sink receives the kind
+func (s *notificationSink) accepts(kind string) bool {
+	return len(s.kinds) == 0 || slices.Contains(s.kinds, kind)
+}
+
+// Return the sinks receiving the kind
+func sinksFor(settings *yamlNotificationConfig, kind string) []*notificationSink {
+	sinks := make([]*notificationSink, 0, len(settings.sinks))
+	for _, sink := range settings.sinks {
+		if sink.accepts(kind) {
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks
+}
+
+// notificationTasks returns a task per sink receiving each alert.
+// Each task writes its outcome to its own element of the returned slice.
+func notificationTasks(settings *yamlContent, alerts []plannedAlert) ([]func(ctx context.Context), []deliveryOutcome) {
+	tasks := make([]func(ctx context.Context), 0)
+	count := 0
+	for _, alert := range alerts {
+		count += len(sinksFor(&settings.NotificationConfigs, alert.kind()))
+	}
+
+	outcomes := make([]deliveryOutcome, count)
+	i := 0
+	for _, alert := range alerts {
+		for _, sink := range sinksFor(&settings.NotificationConfigs, alert.kind()) {
+			index := i
+			tasks = append(tasks, func(ctx context.Context) {
+				outcomes[index] = notify(ctx, httpClientFor(&settings.HttpClientConfigs), sink, alert)
+			})
+			i++
+		}
+	}
+	return tasks, outcomes
+}
+
+// notifyAll sends the alert to every sink receiving it, one after the other
+func notifyAll(ctx context.Context, settings *yamlContent, alert plannedAlert) []deliveryOutcome {
+	outcomes := make([]deliveryOutcome, 0)
+	for _, sink := range sinksFor(&settings.NotificationConfigs, alert.kind()) {
+		outcomes = append(outcomes, notify(ctx, httpClientFor(&settings.HttpClientConfigs), sink, alert))
+	}
+	return outcomes
+}
+
+// notify POSTs the alert to the sink and returns the outcome.
+// The delivery is logged as an event, with the payload redacted and truncated by logging_configs.
+func notify(ctx context.Context, httpClient *http.Client, sink *notificationSink, alert plannedAlert) (result deliveryOutcome) {
+	ctx, span := startClientSpan(ctx, "notify "+alert.name, http.MethodPost, sink.targetUrl, attribute.String("notification.sink", sink.name))
+	var body []byte
+	defer func() {
+		endDeliverySpan(span, result)
+		logFor(ctx).event(deliveryEvent("alert", sink.targetUrl, result).withPayload(currentLoggingConfig(), body))
+	}()
+
+	outcome := startDelivery(alert.name)
+	outcome.Sink = sink.name
+
+	body, err := notificationBody(sink, alert)
+	if err != nil {
+		return outcome.failed(err)
+	}
+
+	ctx, cancel := withTimeout(ctx, sink.timeOut)
+	defer cancel()
+
+	requestBody, err := compressBody(body, sink.contentEncoding)
+	if err != nil {
+		return outcome.failed(err)
+	}
+
+	res, err := postJson(ctx, httpClient, sink.targetUrl, bytes.NewReader(requestBody), sink.contentEncoding)
+	if err != nil {
+		return outcome.failed(err)
+	}
+	res.Body.Close()
+
+	return outcome.completed(res.StatusCode, res.StatusCode >= 200 && res.StatusCode < 300)
+}
+
+// notificationBody returns the request body of the alert in the format of the sink
+func notificationBody(sink *notificationSink, alert plannedAlert) ([]byte, error) {
+	if sink.sinkType == sinkAlertmanager {
+		return marshalAlert(alert.name, alert.entries)
+	}
+
+	summary := notificationSummaries[alert.name]
+	if strings.Contains(summary, "%d") {
+		summary = fmt.Sprintf(summary, len(alert.entries))
+	}
+	data := notificationData{
+		Name:      alert.name,
+		Kind:      alert.kind(),
+		Severity:  "critical",
+		Summary:   summary,
+		Count:     len(alert.entries),
+		Entries:   alert.entries,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	switch sink.sinkType {
+	case sinkWebhook:
+		var buf bytes.Buffer
+		if err := sink.template.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to execute the template of %s: %w", sink.name, err)
+		}
+		// The template may still produce something else than JSON for some data
+		if !json.Valid(buf.Bytes()) {
+			return nil, fmt.Errorf("the template of %s did not produce JSON", sink.name)
+		}
+		return buf.Bytes(), nil
+
+	case sinkSlack:
+		return json.Marshal(gin.H{
+			"text": fmt.Sprintf("*configuration-exporter %s*\n%s\n```%s```", data.Name, data.Summary, chatDetails(alert)),
+		})
+
+	case sinkTeams:
+		return json.Marshal(gin.H{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"themeColor": "D70000",
+			"summary":    data.Summary,
+			"title":      "configuration-exporter " + data.Name,
+			"text":       fmt.Sprintf("%s\n\n```\n%s\n```", data.Summary, chatDetails(alert)),
+		})
+	}
+
+	return nil, fmt.Errorf("sink type %s is not supported", sink.sinkType)
+}
+
+// Return the details of the alert in a message of slack and teams.
+// They are the IDs of the first maxChatDeviceIds devices, or the entry of a self-monitoring alert, which is small.
+func chatDetails(alert plannedAlert) string {
+	if alert.kind() == syncFailure {
+		return toJsonString(alert.entries)
+	}
+
+	listed := alert.entries[:min(len(alert.entries), maxChatDeviceIds)]
+	ids := make([]string, 0, len(listed))
+	for i, entry := range listed {
+		ids = append(ids, entryDeviceId(entry, i))
+	}
+	details := strings.Join(ids, ", ")
+	if more := len(alert.entries) - len(ids); more > 0 {
+		details += fmt.Sprintf(" and %d more", more)
+	}
+	return details
+}
+
+// Return the ID of the device of an alert entry, or its position when it has none
+func entryDeviceId(entry any, index int) string {
+	var object map[string]any
+	switch e := entry.(type) {
+	case map[string]any:
+		object = e
+	case gin.H:
+		object = e
+	}
+	if id, ok := object["deviceID"].(string); ok && id != "" {
+		return id
+	}
+	return fmt.Sprintf("entries[%d]", index)
+}
+
+// Marshal a value for a template, or return the error message when it cannot be marshaled
+func toJsonString(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
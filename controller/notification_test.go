@@ -0,0 +1,209 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_validNotificationConfig(t *testing.T) {
+	timeout := 30
+	sink := func(name, sinkType, template string, kinds ...string) yamlNotificationSink {
+		return yamlNotificationSink{Name: name, Type: sinkType, TargetUrl: "http://localhost/hook", TimeOut: &timeout, Template: template, Kinds: kinds}
+	}
+
+	tests := []struct {
+		name    string
+		sinks   []yamlNotificationSink
+		kinds   []string
+		wantErr bool
+	}{
+		{"Normal case: No sink", nil, nil, false},
+		{"Normal case: Every type of sink", []yamlNotificationSink{
+			sink("am", sinkAlertmanager, ""),
+			sink("hook", sinkWebhook, `{"kind": "{{.Kind}}"}`),
+			sink("slack", sinkSlack, "", syncFailure),
+			sink("teams", sinkTeams, ""),
+		}, nil, false},
+		{"Error case: Duplicate name", []yamlNotificationSink{sink("chat", sinkSlack, ""), sink("chat", sinkTeams, "")}, nil, true},
+		{"Error case: Name of alert_config", []yamlNotificationSink{sink("alert_config", sinkSlack, "")}, nil, true},
+		{"Error case: Unknown type", []yamlNotificationSink{sink("mail", "smtp", "")}, nil, true},
+		{"Error case: Webhook without template", []yamlNotificationSink{sink("hook", sinkWebhook, "")}, nil, true},
+		{"Error case: Webhook with an invalid template", []yamlNotificationSink{sink("hook", sinkWebhook, "{{.Kind")}, nil, true},
+		{"Error case: Webhook template not producing JSON", []yamlNotificationSink{sink("hook", sinkWebhook, "alert {{.Summary}}")}, nil, true},
+		{"Error case: Webhook template failing on the data", []yamlNotificationSink{sink("hook", sinkWebhook, `{"first": {{index .Entries 5}}}`)}, nil, true},
+		{"Error case: Unknown kind", []yamlNotificationSink{sink("chat", sinkSlack, "", "deviceList")}, nil, true},
+		{"Error case: Unknown kind of alert_config", nil, []string{"deviceList"}, true},
+		{"Error case: Invalid URL", []yamlNotificationSink{{Name: "chat", Type: sinkSlack, TargetUrl: "hook"}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := yamlNotificationConfig{Sinks: tt.sinks}
+			alertConfig := yamlAlertConfig{TargetUrl: "http://localhost/alerts", TimeOut: &timeout, Kinds: tt.kinds}
+			err := validNotificationConfig(&settings, &alertConfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validNotificationConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(settings.sinks) != len(tt.sinks)+1 {
+				t.Errorf("validNotificationConfig() sinks = %d, want %d", len(settings.sinks), len(tt.sinks)+1)
+			}
+		})
+	}
+}
+
+func Test_sinksFor(t *testing.T) {
+	timeout := 30
+	settings := yamlNotificationConfig{Sinks: []yamlNotificationSink{
+		{Name: "all", Type: sinkSlack, TargetUrl: "http://localhost/all", TimeOut: &timeout},
+		{Name: "failures", Type: sinkTeams, TargetUrl: "http://localhost/failures", TimeOut: &timeout, Kinds: []string{syncFailure}},
+	}}
+	if err := validNotificationConfig(&settings, &yamlAlertConfig{TargetUrl: "http://localhost/alerts", TimeOut: &timeout}); err != nil {
+		t.Fatalf("validNotificationConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		kind string
+		want []string
+	}{
+		{abnormalStatusDeviceList, []string{"alert_config", "all"}},
+		{syncFailure, []string{"alert_config", "all", "failures"}},
+	}
+	for _, tt := range tests {
+		t.Run("Normal case: "+tt.kind, func(t *testing.T) {
+			sinks := sinksFor(&settings, tt.kind)
+			names := make([]string, 0, len(sinks))
+			for _, sink := range sinks {
+				names = append(names, sink.name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("sinksFor() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func Test_notificationBody(t *testing.T) {
+	timeout := 30
+	settings := yamlNotificationConfig{Sinks: []yamlNotificationSink{
+		{Name: "hook", Type: sinkWebhook, TargetUrl: "http://localhost/hook", TimeOut: &timeout, Template: `{"kind": "{{.Kind}}", "count": {{.Count}}, "devices": {{json .Entries}}}`},
+		{Name: "slack", Type: sinkSlack, TargetUrl: "http://localhost/slack", TimeOut: &timeout},
+		{Name: "teams", Type: sinkTeams, TargetUrl: "http://localhost/teams", TimeOut: &timeout},
+	}}
+	if err := validNotificationConfig(&settings, &yamlAlertConfig{TargetUrl: "http://localhost/alerts", TimeOut: &timeout}); err != nil {
+		t.Fatalf("validNotificationConfig() error = %v", err)
+	}
+	alert := plannedAlert{abnormalStatusDeviceList, []any{map[string]any{"deviceID": "dev1"}}}
+
+	tests := []struct {
+		name    string
+		sink    *notificationSink
+		wantKey string
+	}{
+		{"Normal case: Alertmanager", settings.sinks[0], ""},
+		{"Normal case: Webhook template", settings.sinks[1], "devices"},
+		{"Normal case: Slack", settings.sinks[2], "text"},
+		{"Normal case: Teams", settings.sinks[3], "@type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := notificationBody(tt.sink, alert)
+			if err != nil {
+				t.Fatalf("notificationBody() error = %v", err)
+			}
+			if tt.wantKey == "" {
+				var alerts alertContentList
+				if err := json.Unmarshal(body, &alerts); err != nil || alerts[0].Labels.Alertname != abnormalStatusDeviceList {
+					t.Errorf("notificationBody() = %s, want an Alertmanager alert", body)
+				}
+				return
+			}
+			var object map[string]any
+			if err := json.Unmarshal(body, &object); err != nil {
+				t.Fatalf("notificationBody() = %s is not a JSON object: %v", body, err)
+			}
+			if _, ok := object[tt.wantKey]; !ok {
+				t.Errorf("notificationBody() = %s, want key %s", body, tt.wantKey)
+			}
+		})
+	}
+}
+
+func Test_chatDetails(t *testing.T) {
+	many := make([]any, 0, maxChatDeviceIds+5)
+	for i := range maxChatDeviceIds + 5 {
+		many = append(many, map[string]any{"deviceID": fmt.Sprintf("dev%d", i), "status": map[string]any{"state": "Disabled"}})
+	}
+
+	tests := []struct {
+		name  string
+		alert plannedAlert
+		want  string
+	}{
+		{
+			"Normal case: IDs of the devices",
+			plannedAlert{abnormalStatusDeviceList, []any{map[string]any{"deviceID": "dev1", "type": "CPU"}, map[string]any{"deviceID": "dev2", "type": "CPU"}}},
+			"dev1, dev2",
+		},
+		{
+			"Normal case: Only the first devices are listed",
+			plannedAlert{abnormalStatusDeviceList, many},
+			"dev0, dev1, dev2, dev3, dev4, dev5, dev6, dev7, dev8, dev9, dev10, dev11, dev12, dev13, dev14, dev15, dev16, dev17, dev18, dev19 and 5 more",
+		},
+		{
+			"Normal case: Entries without ID",
+			plannedAlert{invalidDeviceList, []any{gin.H{"deviceID": "", "index": 3}, "entry"}},
+			"entries[0], entries[1]",
+		},
+		{
+			"Normal case: Entry of a self-monitoring alert",
+			plannedAlert{syncCollectFailure, []any{gin.H{"phase": "collect"}}},
+			`[{"phase":"collect"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chatDetails(tt.alert); got != tt.want {
+				t.Errorf("chatDetails() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_notify(t *testing.T) {
+	var received []byte
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	sink := &notificationSink{name: "slack", sinkType: sinkSlack, targetUrl: testServer.URL}
+	got := notify(context.Background(), httpClientFor(&yamlHttpClientConfig{}), sink, plannedAlert{syncCollectFailure, []any{map[string]any{"phase": "collect"}}})
+	if !got.Succeeded || got.Sink != "slack" || got.AlertName != syncCollectFailure {
+		t.Errorf("notify() outcome = %+v", got)
+	}
+	if len(received) == 0 {
+		t.Errorf("notify() sent an empty body")
+	}
+}
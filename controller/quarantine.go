@@ -0,0 +1,121 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Alert name of the devices that do not conform to the device schema
+const invalidDeviceList string = "invalidDeviceList"
+
+// quarantinedDevice is a device excluded from the forward because it does not conform to the device schema
+type quarantinedDevice struct {
+	DeviceID string        `json:"deviceID,omitempty"`
+	Index    int           `json:"index"`
+	Errors   []deviceIssue `json:"errors"`
+	Device   Device        `json:"device"`
+}
+
+// quarantineList is the quarantine of the latest sync
+type quarantineList struct {
+	InfoTimestamp string              `json:"infoTimestamp"`
+	QuarantinedAt time.Time           `json:"quarantinedAt"`
+	Count         int                 `json:"count"`
+	Devices       []quarantinedDevice `json:"devices"`
+}
+
+// Quarantine of the latest sync, replaced by every sync that collects devices
+var quarantine struct {
+	sync.RWMutex
+	list quarantineList
+}
+
+// splitInvalidDevices validates every device against the schema.
+// It returns the devices that conform and the ones that do not, with their validation errors.
+func splitInvalidDevices(ctx context.Context, schema *jsonschema.Schema, devices []Device) ([]Device, []quarantinedDevice) {
+	valid := make([]Device, 0, len(devices))
+	invalid := make([]quarantinedDevice, 0)
+
+	for i := range devices {
+		issues := validateDevice(schema, &devices[i])
+		if issues == nil {
+			valid = append(valid, devices[i])
+			continue
+		}
+
+		for _, issue := range issues {
+			logFor(ctx).Warn(fmt.Sprintf("device %s is quarantined. %s", deviceLabel(&devices[i], i), issue))
+		}
+		invalid = append(invalid, quarantinedDevice{
+			DeviceID: devices[i].ID,
+			Index:    i,
+			Errors:   issues,
+			Device:   devices[i],
+		})
+	}
+
+	return valid, invalid
+}
+
+// Replace the quarantine with the invalid devices of the latest sync
+func updateQuarantine(infoTimestamp string, invalid []quarantinedDevice) {
+	quarantine.Lock()
+	defer quarantine.Unlock()
+
+	quarantine.list = quarantineList{
+		InfoTimestamp: infoTimestamp,
+		QuarantinedAt: time.Now().UTC(),
+		Count:         len(invalid),
+		Devices:       invalid,
+	}
+}
+
+// Return the alert entries of the invalid devices: their ID or position, and their validation errors
+func quarantineAlerts(invalid []quarantinedDevice) []any {
+	alerts := make([]any, 0, len(invalid))
+	for _, device := range invalid {
+		alerts = append(alerts, gin.H{
+			"deviceID": device.DeviceID,
+			"index":    device.Index,
+			"errors":   device.Errors,
+		})
+	}
+	return alerts
+}
+
+// GetQuarantinedDevices returns the devices of the latest sync that did not conform to the device schema
+// and were therefore not forwarded to configuration-manager.
+//
+// Response Codes:
+//   - 200 OK: Returned with the quarantine list. It is empty until a sync has collected devices.
+func GetQuarantinedDevices(c *gin.Context) {
+	quarantine.RLock()
+	list := quarantine.list
+	quarantine.RUnlock()
+
+	if list.Devices == nil {
+		list.Devices = []quarantinedDevice{}
+	}
+
+	c.JSON(http.StatusOK, list)
+}
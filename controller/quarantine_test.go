@@ -0,0 +1,138 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_loadDeviceSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings yamlValidationConfig
+		wantErr  bool
+	}{
+		{"Normal case: Built-in schema when schema_file is omitted", yamlValidationConfig{}, false},
+		{"Normal case: Schema file", yamlValidationConfig{SchemaFile: "testdata/device_schema.json"}, false},
+		{"Error case: Schema file does not exist", yamlValidationConfig{SchemaFile: "testdata/aaa.json"}, true},
+		{"Error case: Schema file is not a valid schema", yamlValidationConfig{SchemaFile: "testdata/device_schema_invalid.json"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := loadDeviceSchema(&tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadDeviceSchema() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && schema == nil {
+				t.Error("loadDeviceSchema() returned no schema")
+			}
+		})
+	}
+}
+
+func Test_splitInvalidDevices(t *testing.T) {
+	schema, err := loadDeviceSchema(&yamlValidationConfig{SchemaFile: "testdata/device_schema.json"})
+	if err != nil {
+		t.Fatalf("loadDeviceSchema() error = %v", err)
+	}
+
+	devices := []Device{
+		newDevice(map[string]any{"deviceID": "dev1", "type": "CPU", "status": map[string]any{}, "deviceKeys": map[string]any{}}),
+		newDevice(map[string]any{"deviceID": "dev2", "type": "CPU", "status": map[string]any{}}),
+		newDevice(map[string]any{"type": "CPU"}),
+	}
+
+	valid, invalid := splitInvalidDevices(context.Background(), schema, devices)
+
+	if len(valid) != 1 || valid[0].ID != "dev1" {
+		t.Errorf("splitInvalidDevices() valid = %+v, want dev1 only", valid)
+	}
+	if len(invalid) != 2 || invalid[0].DeviceID != "dev2" || invalid[1].Index != 2 {
+		t.Fatalf("splitInvalidDevices() invalid = %+v, want dev2 and deviceList[2]", invalid)
+	}
+	for _, device := range invalid {
+		if len(device.Errors) == 0 {
+			t.Errorf("splitInvalidDevices() %+v has no validation error", device)
+		}
+	}
+}
+
+func TestGetQuarantinedDevices(t *testing.T) {
+	updateQuarantine("2025-01-01T00:00:00Z", []quarantinedDevice{
+		{DeviceID: "dev2", Index: 1, Errors: []deviceIssue{{Path: "", Message: "missing property 'deviceKeys'"}}},
+	})
+	defer updateQuarantine("", nil)
+
+	w := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(w)
+	ginContext.Request = httptest.NewRequest(http.MethodGet, "/cdim/api/v1/devices/quarantine", nil)
+
+	GetQuarantinedDevices(ginContext)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetQuarantinedDevices() status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var got quarantineList
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("GetQuarantinedDevices() body is not JSON: %v", err)
+	}
+	if got.Count != 1 || got.Devices[0].DeviceID != "dev2" || got.InfoTimestamp != "2025-01-01T00:00:00Z" {
+		t.Errorf("GetQuarantinedDevices() = %+v", got)
+	}
+}
+
+func Test_planSync_incompleteStatus(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"deviceList": [
+			{"deviceID": "dev1", "type": "CPU"},
+			{"deviceID": "dev2", "type": "CPU", "status": {"state": "Enabled"}},
+			{"type": "CPU", "status": {"state": "Enabled", "health": "OK"}}
+		], "infoTimestamp": "2025-01-01T00:00:00Z"}`))
+	}))
+	defer testServer.Close()
+
+	settings := yamlContent{}
+	if err := loadConfig("testdata/exporter.yaml", &settings); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	settings.CollectConfigs.TargetUrl = testServer.URL
+
+	plan, err := planSync(context.Background(), &settings, nil)
+	if err != nil {
+		t.Fatalf("planSync() error = %v", err)
+	}
+
+	// The device without ID is quarantined, the devices with an incomplete status are forwarded and abnormal
+	if len(plan.invalidDevices) != 1 || plan.invalidDevices[0].Index != 2 {
+		t.Errorf("planSync() quarantined = %+v, want the device without ID", plan.invalidDevices)
+	}
+	if plan.result.ForwardedDevices != 2 || len(plan.abnormal) != 2 {
+		t.Fatalf("planSync() forwarded = %d, abnormal = %+v, want 2 and 2", plan.result.ForwardedDevices, plan.abnormal)
+	}
+	if rule := plan.abnormal[0].Evaluation.Rule; rule != ruleStatusRequired {
+		t.Errorf("evaluation of the device without status = %s, want %s", rule, ruleStatusRequired)
+	}
+	if evaluation := plan.abnormal[1].Evaluation; evaluation.Rule != ruleValueRequired || evaluation.Field != "status.health" {
+		t.Errorf("evaluation of the device without health = %+v, want %s of status.health", evaluation, ruleValueRequired)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header carrying the ID of a request, read from the inbound requests, echoed in the responses
+// and set on the collect, forward and alert requests
+const requestIdHeader string = "X-Request-ID"
+
+// Context key of the ID of the request, or of the sync it started
+const requestIdKey string = "requestId"
+
+// An inbound ID is kept only when it is made of these characters, so that it cannot forge log lines
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIdContextKey struct{}
+
+// RequestIdMiddleware sets the ID of every request, taken from its X-Request-ID header or generated,
+// in the gin context and in the request context, and echoes it in the X-Request-ID response header.
+// It runs before the audit trail middleware, so that the trail can record the ID.
+func RequestIdMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIdHeader)
+		if !validRequestId.MatchString(id) {
+			id = newRequestId()
+		}
+
+		c.Set(requestIdKey, id)
+		c.Request = c.Request.WithContext(withRequestId(c.Request.Context(), id))
+		c.Header(requestIdHeader, id)
+		c.Next()
+	}
+}
+
+// RequestIdOf returns the ID of the request, generating it when RequestIdMiddleware did not run
+func RequestIdOf(c *gin.Context) string {
+	if id := c.GetString(requestIdKey); id != "" {
+		return id
+	}
+
+	id := newRequestId()
+	c.Set(requestIdKey, id)
+	return id
+}
+
+// Generate a random request ID
+func newRequestId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// withRequestId returns a copy of ctx carrying the request ID
+func withRequestId(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIdContextKey{}, id)
+}
+
+// requestIdFrom returns the request ID carried by ctx, or an empty string
+func requestIdFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdContextKey{}).(string)
+	return id
+}
+
+// setRequestIdHeader forwards the request ID carried by ctx to an outbound request
+func setRequestIdHeader(ctx context.Context, req *http.Request) {
+	if id := requestIdFrom(ctx); id != "" {
+		req.Header.Set(requestIdHeader, id)
+	}
+}
+
+// requestLogger writes to the application log, prefixing each line with the request ID
+type requestLogger struct {
+	id string
+}
+
+// logFor returns the logger of the request carried by ctx.
+// Outside of a request, the lines are written without prefix.
+func logFor(ctx context.Context) requestLogger {
+	return requestLogger{id: requestIdFrom(ctx)}
+}
+
+func (l requestLogger) prefix(msg string) string {
+	if l.id == "" {
+		return msg
+	}
+	return fmt.Sprintf("[requestId = %s] %s", l.id, msg)
+}
+
+func (l requestLogger) Debug(msg string) {
+	log.Debug(l.prefix(msg))
+}
+
+func (l requestLogger) Info(msg string) {
+	log.Info(l.prefix(msg))
+}
+
+func (l requestLogger) Warn(msg string) {
+	log.Warn(l.prefix(msg))
+}
+
+// Error writes an error line, the optional flag is passed to the application logger as is
+func (l requestLogger) Error(msg string, stack ...bool) {
+	log.Error(l.prefix(msg), stack...)
+}
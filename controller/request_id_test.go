@@ -0,0 +1,102 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_RequestIdMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		inbound  string
+		wantKept bool
+	}{
+		{"Normal case: Inbound ID is kept", "sync-2025.01:a_b", true},
+		{"Normal case: ID is generated when absent", "", false},
+		{"Error case: ID with spaces is replaced", "id with spaces", false},
+		{"Error case: Too long ID is replaced", strings.Repeat("a", 129), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotContext, gotRequest string
+			router := gin.New()
+			router.Use(RequestIdMiddleware())
+			router.GET("/", func(c *gin.Context) {
+				gotContext = RequestIdOf(c)
+				gotRequest = requestIdFrom(c.Request.Context())
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.inbound != "" {
+				req.Header.Set(requestIdHeader, tt.inbound)
+			}
+			router.ServeHTTP(w, req)
+
+			echoed := w.Header().Get(requestIdHeader)
+			if echoed == "" || echoed != gotContext || echoed != gotRequest {
+				t.Errorf("RequestIdMiddleware() response = %s, gin context = %s, request context = %s", echoed, gotContext, gotRequest)
+			}
+			if (echoed == tt.inbound) != tt.wantKept {
+				t.Errorf("RequestIdMiddleware() = %s, inbound %s, wantKept %v", echoed, tt.inbound, tt.wantKept)
+			}
+		})
+	}
+}
+
+func Test_postJson_requestId(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(requestIdHeader)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"Normal case: ID is forwarded", withRequestId(context.Background(), "req-1"), "req-1"},
+		{"Normal case: No ID outside of a request", context.Background(), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := postJson(tt.ctx, server.Client(), server.URL, strings.NewReader("{}"), "")
+			if err != nil {
+				t.Fatalf("postJson() error = %v", err)
+			}
+			res.Body.Close()
+			if got != tt.want {
+				t.Errorf("postJson() %s = %s, want %s", requestIdHeader, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_requestLogger_prefix(t *testing.T) {
+	if got := logFor(withRequestId(context.Background(), "req-1")).prefix("start."); got != "[requestId = req-1] start." {
+		t.Errorf("prefix() = %s", got)
+	}
+	if got := logFor(context.Background()).prefix("start."); got != "start." {
+		t.Errorf("prefix() without ID = %s", got)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"maps"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rules of the status evaluation
+const (
+	// status.state and status.health are both normal
+	ruleNormal string = "normal"
+	// status must be a map
+	ruleStatusRequired string = "statusRequired"
+	// status.state and status.health must be strings
+	ruleValueRequired string = "valueRequired"
+	// status.state and status.health must be one of alert_config/state_settings/normal_state and normal_health
+	ruleNormalValue string = "normalValue"
+)
+
+// Key of the status evaluation added to the devices of the alert of abnormalStatusDeviceList
+const statusEvaluationKey string = "statusEvaluation"
+
+// statusEvaluation is the verdict on the status of a device, with the rule that decided it
+type statusEvaluation struct {
+	Normal bool   `json:"normal"`
+	Rule   string `json:"rule"`
+	// Path of the field the rule applies to
+	Field string `json:"field,omitempty"`
+	// Value of the field as collected, omitted when the field does not exist
+	Observed any `json:"observed,omitempty"`
+	// Values accepted as normal
+	Expected []string `json:"expected,omitempty"`
+	Message  string   `json:"message,omitempty"`
+}
+
+// statusEvaluationResult is the response of the status evaluation API
+type statusEvaluationResult struct {
+	InfoTimestamp string `json:"infoTimestamp"`
+	DeviceID      string `json:"deviceID"`
+	// Whether the status of the device was classified, false when it was quarantined or filtered
+	Classified bool              `json:"classified"`
+	Evaluation *statusEvaluation `json:"evaluation,omitempty"`
+	// Why the device was not classified
+	Reason string `json:"reason,omitempty"`
+}
+
+// alertEntry returns the entry of the alert of abnormalStatusDeviceList: the device as collected, with its evaluation
+func (d abnormalDevice) alertEntry() map[string]any {
+	entry := maps.Clone(d.Device.Raw)
+	if entry == nil {
+		entry = map[string]any{}
+	}
+	entry[statusEvaluationKey] = d.Evaluation
+	return entry
+}
+
+// GetStatusEvaluation returns why a device of the latest full sync was classified normal or abnormal,
+// as evaluated by that sync. A device quarantined or filtered by the sync was not classified,
+// the response then tells why instead.
+//
+// Response Codes:
+//   - 200 OK: Returned with the evaluation of the status of the device, or why it was not classified.
+//   - 404 Not Found: Returned when the latest full sync did not collect a device with the ID.
+func GetStatusEvaluation(c *gin.Context) {
+	id := c.Param("id")
+
+	classification.RLock()
+	result := statusEvaluationResult{InfoTimestamp: classification.infoTimestamp, DeviceID: id}
+	evaluation, classified := classification.evaluations[id]
+	reason, collected := classification.unclassified[id]
+	classification.RUnlock()
+
+	switch {
+	case classified:
+		result.Classified = true
+		result.Evaluation = &evaluation
+	case collected:
+		result.Reason = reason
+	default:
+		err := errDeviceNotFound.New(id)
+		logFor(c.Request.Context()).Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_abnormalDevice_alertEntry(t *testing.T) {
+	device := abnormalDevice{
+		Device:     newDevice(map[string]any{"deviceID": "cpu1", "status": map[string]any{"state": "Disabled"}}),
+		Evaluation: statusEvaluation{Rule: ruleNormalValue, Field: "status.state", Observed: "Disabled", Expected: []string{"Enabled"}},
+	}
+
+	entry := device.alertEntry()
+
+	if entry["deviceID"] != "cpu1" || entry[statusEvaluationKey].(statusEvaluation).Field != "status.state" {
+		t.Errorf("alertEntry() = %v", entry)
+	}
+	if _, ok := device.Device.Raw[statusEvaluationKey]; ok {
+		t.Errorf("alertEntry() modified the device: %v", device.Device.Raw)
+	}
+	if entry := (abnormalDevice{}).alertEntry(); len(entry) != 1 {
+		t.Errorf("alertEntry() of a device without object = %v", entry)
+	}
+}
+
+func TestGetStatusEvaluation(t *testing.T) {
+	resetClassification(t)
+	settings := yamlStateSetting{NormalState: []string{"Enabled"}, NormalHealth: []string{"OK", "Warning"}}
+	plan := &syncPlan{timestamp: "2025-01-01T00:00:00Z", evaluations: map[string]statusEvaluation{}}
+	if err := json.Unmarshal([]byte(inventoryDevices), &plan.collected.Devices); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	// cpu1 is quarantined and fan1 filtered, the other devices are classified
+	plan.collected.Devices = append(plan.collected.Devices, newDevice(map[string]any{"deviceID": "fan1", "type": "fan"}))
+	plan.invalidDevices = []quarantinedDevice{{DeviceID: "cpu1", Device: plan.collected.Devices[0]}}
+	for _, device := range plan.collected.Devices[1:4] {
+		plan.evaluations[device.ID] = evaluateStatus(device, settings)
+	}
+	updateClassification(plan, time.Now())
+
+	tests := []struct {
+		name         string
+		id           string
+		wantStatus   int
+		wantReason   string
+		wantNormal   bool
+		wantRule     string
+		wantField    string
+		wantObserved any
+		wantExpected []string
+	}{
+		{"Normal case: Normal device", "mem1", http.StatusOK, "", true, ruleNormal, "", nil, nil},
+		{"Normal case: Abnormal state", "cpu2", http.StatusOK, "", false, ruleNormalValue, "status.state", "Disabled", []string{"Enabled"}},
+		{"Normal case: Device without status", "nic1", http.StatusOK, "", false, ruleStatusRequired, "status", nil, nil},
+		{"Normal case: Quarantined device", "cpu1", http.StatusOK, quarantinedReason, false, "", "", nil, nil},
+		{"Normal case: Filtered device", "fan1", http.StatusOK, filteredReason, false, "", "", nil, nil},
+		{"Error case: Device not collected", "cpu9", http.StatusNotFound, "", false, "", "", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodGet, "/cdim/api/v1/devices/"+tt.id+"/status-evaluation", nil)
+			ginContext.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			GetStatusEvaluation(ginContext)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GetStatusEvaluation() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Code != http.StatusOK {
+				if !strings.Contains(w.Body.String(), `"0036"`) {
+					t.Errorf("GetStatusEvaluation() body = %s, want code 0036", w.Body.String())
+				}
+				return
+			}
+			var got statusEvaluationResult
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("GetStatusEvaluation() body is not JSON: %v", err)
+			}
+			if got.DeviceID != tt.id || got.Reason != tt.wantReason || got.Classified != (tt.wantReason == "") {
+				t.Fatalf("GetStatusEvaluation() = %s", w.Body.String())
+			}
+			if tt.wantReason != "" {
+				if got.Evaluation != nil {
+					t.Errorf("GetStatusEvaluation() = %s, want no evaluation", w.Body.String())
+				}
+				return
+			}
+			evaluation := got.Evaluation
+			if evaluation == nil || evaluation.Normal != tt.wantNormal || evaluation.Rule != tt.wantRule || evaluation.Field != tt.wantField ||
+				evaluation.Observed != tt.wantObserved || !slices.Equal(evaluation.Expected, tt.wantExpected) {
+				t.Errorf("GetStatusEvaluation() = %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Context of the server lifetime.
+// The background phases of a sync (forward and alerts) are derived from it,
+// because the request context is cancelled as soon as the response has been returned.
+var serverCtx, cancelServerCtx = context.WithCancel(context.Background())
+
+// Background phases that have not finished yet
+var inflight sync.WaitGroup
+
+// Closed when the server starts shutting down. The callers waiting for a sync are then answered without waiting.
+var shuttingDown struct {
+	sync.Mutex
+	ch     chan struct{}
+	closed bool
+}
+
+// BeginShutdown answers the callers waiting for a sync, so that the server does not wait for them to shut down.
+// The background phases of the syncs continue until Shutdown.
+func BeginShutdown() {
+	shuttingDown.Lock()
+	defer shuttingDown.Unlock()
+
+	if !shuttingDown.closed {
+		close(shutdownSignal())
+		shuttingDown.closed = true
+	}
+}
+
+// Return the channel closed by BeginShutdown. The caller holds the lock.
+func shutdownSignal() chan struct{} {
+	if shuttingDown.ch == nil {
+		shuttingDown.ch = make(chan struct{})
+	}
+	return shuttingDown.ch
+}
+
+// Return a channel closed when the server starts shutting down
+func shutdownStarted() <-chan struct{} {
+	shuttingDown.Lock()
+	defer shuttingDown.Unlock()
+	return shutdownSignal()
+}
+
+// runInBackground runs the tasks concurrently in the background.
+// Each task receives a context derived from the server context that expires at the deadline of the sync.
+// It carries the request ID and the span of parent, the request that started the sync, but not its cancellation.
+// The returned channel is closed once every task has returned.
+func runInBackground(parent context.Context, deadline time.Time, tasks ...func(ctx context.Context)) <-chan struct{} {
+	ctx := trace.ContextWithSpan(withRequestId(serverCtx, requestIdFrom(parent)), trace.SpanFromContext(parent))
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+		inflight.Add(1)
+		go func() {
+			defer inflight.Done()
+			defer wg.Done()
+			task(ctx)
+		}()
+	}
+
+	// Release the context once every task has returned
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		cancel()
+		close(done)
+	}()
+
+	return done
+}
+
+// Shutdown cancels the background phases of all syncs in progress and waits for them to return.
+// It returns the error of ctx if they did not return before ctx is done.
+func Shutdown(ctx context.Context) error {
+	cancelServerCtx()
+
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// withTimeout derives a context limited by a timeout in seconds.
+// As with http.Client, a nil or non-positive timeout means no timeout.
+func withTimeout(ctx context.Context, timeout *int) (context.Context, context.CancelFunc) {
+	if timeout == nil || *timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, toDuration(timeout))
+}
+
+// Convert a timeout in seconds to time.Duration
+func toDuration(timeout *int) time.Duration {
+	if timeout == nil {
+		return 0
+	}
+	return time.Duration(*timeout) * time.Second
+}
+
+// contextError replaces err with a more specific error when ctx has ended,
+// so that timeouts and cancellations are distinguishable from other request failures.
+func contextError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return errTimeout.Wrap(err)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return errCancelled.Wrap(err)
+	}
+	return err
+}
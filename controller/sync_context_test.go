@@ -0,0 +1,75 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_runInBackground(t *testing.T) {
+	done := make(chan error, 2)
+	task := func(ctx context.Context) {
+		if id := requestIdFrom(ctx); id != "req-1" {
+			t.Errorf("runInBackground() task request ID = %s, want req-1", id)
+		}
+		<-ctx.Done()
+		done <- ctx.Err()
+	}
+
+	finished := runInBackground(withRequestId(context.Background(), "req-1"), time.Now().Add(50*time.Millisecond), task, task)
+
+	for range 2 {
+		select {
+		case err := <-done:
+			if err != context.DeadlineExceeded {
+				t.Errorf("runInBackground() task ended with %v, want %v", err, context.DeadlineExceeded)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("runInBackground() task was not stopped at the deadline")
+		}
+	}
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runInBackground() did not report that the tasks have returned")
+	}
+}
+
+func Test_withTimeout(t *testing.T) {
+	zero := 0
+	one := 1
+
+	tests := []struct {
+		name         string
+		timeout      *int
+		wantDeadline bool
+	}{
+		{"Normal case: nil timeout means no timeout", nil, false},
+		{"Normal case: Zero timeout means no timeout", &zero, false},
+		{"Normal case: Positive timeout sets a deadline", &one, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := withTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			if _, ok := ctx.Deadline(); ok != tt.wantDeadline {
+				t.Errorf("withTimeout() deadline set = %v, want %v", ok, tt.wantDeadline)
+			}
+		})
+	}
+}
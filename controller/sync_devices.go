@@ -15,8 +15,9 @@
 package controller
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -27,41 +28,68 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 	yaml "gopkg.in/yaml.v2"
 )
 
 const (
-	defaultTimeout int    = 600
-	maxTimeout     int    = 36000
-	minTimeout     int    = 1
-	yamlFilePath   string = "configs/exporter.yaml"
+	defaultTimeout int = 600
+	maxTimeout     int = 36000
+	minTimeout     int = 1
 )
 
+// Path of the settings, replaced by the tests of the handlers
+var yamlFilePath string = "configs/exporter.yaml"
+
 const (
 	incompleteDeviceList     string = "incompleteDeviceList"
 	abnormalStatusDeviceList string = "abnormalStatusDeviceList"
 )
 
 type yamlContent struct {
-	CollectConfigs yamlCollectConfig `yaml:"collect_configs"`
-	ForwardConfigs yamlForwardConfig `yaml:"forward_configs"`
-	AlertConfigs   yamlAlertConfig   `yaml:"alert_config"`
+	SyncConfigs         yamlSyncConfig         `yaml:"sync_configs"`
+	TracingConfigs      yamlTracingConfig      `yaml:"tracing_configs"`
+	LoggingConfigs      yamlLoggingConfig      `yaml:"logging_configs"`
+	AuditConfigs        yamlAuditConfig        `yaml:"audit_configs"`
+	HistoryConfigs      yamlHistoryConfig      `yaml:"history_configs"`
+	EventConfigs        yamlEventConfig        `yaml:"event_configs"`
+	HttpClientConfigs   yamlHttpClientConfig   `yaml:"http_client_configs"`
+	CollectConfigs      yamlCollectConfig      `yaml:"collect_configs"`
+	ForwardConfigs      yamlForwardConfig      `yaml:"forward_configs"`
+	AlertConfigs        yamlAlertConfig        `yaml:"alert_config"`
+	NotificationConfigs yamlNotificationConfig `yaml:"notification_configs"`
+	ValidationConfigs   yamlValidationConfig   `yaml:"validation_configs"`
+	TransformConfigs    yamlTransformConfig    `yaml:"transform_configs"`
+	FilterConfigs       yamlFilterConfig       `yaml:"filter_configs"`
+	MaintenanceConfigs  yamlMaintenanceConfig  `yaml:"maintenance_configs"`
+	MonitoringConfigs   yamlMonitoringConfig   `yaml:"monitoring_configs"`
+	DeviceLabels        map[string][]string    `yaml:"device_labels"`
+}
+
+type yamlSyncConfig struct {
+	TimeOut *int `yaml:"timeout"`
 }
 
 type yamlCollectConfig struct {
-	TargetUrl string `yaml:"target_url"`
-	TimeOut   *int   `yaml:"timeout"`
+	TargetUrl       string   `yaml:"target_url"`
+	TimeOut         *int     `yaml:"timeout"`
+	MaxResponseSize *int64   `yaml:"max_response_size"`
+	AcceptEncoding  []string `yaml:"accept_encoding"`
+	DeviceIdQuery   string   `yaml:"device_id_query"`
 }
 
 type yamlForwardConfig struct {
-	TargetUrl string `yaml:"target_url"`
-	TimeOut   *int   `yaml:"timeout"`
+	TargetUrl       string `yaml:"target_url"`
+	TimeOut         *int   `yaml:"timeout"`
+	ContentEncoding string `yaml:"content_encoding"`
 }
 
 type yamlAlertConfig struct {
-	TargetUrl     string           `yaml:"target_url"`
-	TimeOut       *int             `yaml:"timeout"`
-	StateSettings yamlStateSetting `yaml:"state_settings"`
+	TargetUrl       string           `yaml:"target_url"`
+	TimeOut         *int             `yaml:"timeout"`
+	ContentEncoding string           `yaml:"content_encoding"`
+	Kinds           []string         `yaml:"kinds"`
+	StateSettings   yamlStateSetting `yaml:"state_settings"`
 }
 
 type yamlStateSetting struct {
@@ -70,9 +98,9 @@ type yamlStateSetting struct {
 }
 
 type Output struct {
-	Devices           []map[string]any `json:"deviceList"`
-	IncompleteDevices []any            `json:"incompleteDeviceList"`
-	TimeStamp         string           `json:"infoTimestamp"`
+	Devices           []Device `json:"deviceList"`
+	IncompleteDevices []any    `json:"incompleteDeviceList"`
+	TimeStamp         string   `json:"infoTimestamp"`
 }
 
 type alertContent struct {
@@ -117,73 +145,344 @@ func NewAlertContentList(alertName, severity, description string) alertContentLi
 // edit the obtained data into the format of HW information synchronization input for configuration-manager.
 // Forward the edited data to configuration-manager.
 //
+// The collection is bound to the request context, so it stops when the caller goes away.
+// The forward and the alerts continue in the background after the response has been returned
+// and are bound to the server lifetime instead. Every phase is limited by its own timeout
+// and the whole synchronization is limited by sync_configs/timeout.
+//
+// With the query parameter dryRun=true, nothing is forwarded nor alerted and the quarantine is left unchanged.
+// The payloads that would have been sent are returned instead.
+//
+// The optional request body restricts the sync to some devices, see syncTarget.
+// The forward of such a sync is flagged as partial and the quarantine is left unchanged.
+//
+// With the query parameter wait=true, the response is returned once the forward and the alerts have finished,
+// which takes at most sync_configs/timeout. The result then includes their outcomes and the duration of the sync.
+// When the server starts shutting down meanwhile, the device counts are returned at once as without wait.
+//
 // Response Codes:
-//   - 202 Accepted: Returned when the synchronization process is successfully initiated.
+//   - 200 OK: Returned with the payloads that would have been sent, in dry-run mode.
+//     Returned with the result of the sync when waiting and the forward and every alert succeeded.
+//   - 202 Accepted: Returned with the device counts of the sync when the synchronization process is successfully initiated.
+//   - 400 Bad Request: Returned when the dryRun or wait query parameter is not a boolean, or the request body is invalid.
+//   - 502 Bad Gateway: Returned with the result of the sync when waiting and the forward or an alert failed.
 //   - 500 Internal Server Error: Returned when an error occurs during any step of the process.
+//   - 504 Gateway Timeout: Returned when the collection did not finish within its deadline.
 func SyncDevices(c *gin.Context) {
-	log.Info(c.Request.URL.Path + "[" + c.Request.Method + "] start.")
+	// Every line logged and every request sent for the sync carries the ID of the request
+	requestCtx := withRequestId(c.Request.Context(), RequestIdOf(c))
+	logger := logFor(requestCtx)
+
+	logger.Info(c.Request.URL.Path + "[" + c.Request.Method + "] start.")
+	start := time.Now()
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		err = errQueryParameter.Wrap(err, "dryRun")
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	wait, err := strconv.ParseBool(c.DefaultQuery("wait", "false"))
+	if err != nil {
+		err = errQueryParameter.Wrap(err, "wait")
+		logger.Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+
+	// Every sync but a dry run is recorded in the run history
+	run := newSyncRun(c, start)
 
 	settings := yamlContent{}
+	_, span := tracer.Start(requestCtx, "loadConfig")
+	err = loadConfig(yamlFilePath, &settings)
+	endSpan(span, err)
+	if err != nil {
+		logger.Error(err.Error())
+		if !dryRun {
+			recordSyncRun(run.failed("config", err))
+		}
+		// The failure is notified with the settings of the latest sync, if any
+		if lastSettings := rememberedSettings(); lastSettings != nil && !dryRun {
+			runInBackground(requestCtx, time.Now().Add(toDuration(lastSettings.SyncConfigs.TimeOut)), func(ctx context.Context) {
+				recordSyncFailure(ctx, lastSettings, syncCollectFailure, "config", err)
+			})
+		}
+		writeProblem(c, err)
+		return
+	}
+	applyLoggingConfig(&settings.LoggingConfigs)
+	applyAuditConfig(&settings.AuditConfigs)
+	applyHistoryConfig(&settings.HistoryConfigs)
+	if !dryRun {
+		rememberSettings(&settings)
+	}
 
-	err := loadConfig(yamlFilePath, &settings)
+	target, err := parseSyncTarget(c, settings.DeviceLabels)
 	if err != nil {
-		log.Error(err.Error())
-		c.JSON(GetStatusCode(err), ToJson(err))
+		logger.Error(err.Error())
+		writeProblem(c, err)
 		return
 	}
 
-	output := Output{}
-	err = requestDevices(&settings, &output)
+	// The deadline of the whole synchronization, shared by the collection and the background phases
+	deadline := time.Now().Add(toDuration(settings.SyncConfigs.TimeOut))
+
+	collectCtx, cancel := context.WithDeadline(requestCtx, deadline)
+	defer cancel()
+
+	plan, err := planSync(collectCtx, &settings, target)
+	// A caller that goes away cancels the collection. The sync did not fail, it is neither recorded nor alerted on.
+	if errors.Is(err, errCancelled) && c.Request.Context().Err() != nil {
+		logger.Warn(c.Request.URL.Path + "[" + c.Request.Method + "] the caller went away. The sync is abandoned.")
+		return
+	}
 	if err != nil {
-		log.Error(err.Error())
-		c.JSON(GetStatusCode(err), ToJson(err))
+		logger.Error(err.Error())
+		if !dryRun {
+			recordSyncRun(run.failed("collect", err))
+			runInBackground(requestCtx, deadline, func(ctx context.Context) {
+				recordSyncFailure(ctx, &settings, syncCollectFailure, "collect", err)
+			})
+		}
+		writeProblem(c, err)
+		return
+	}
+
+	if dryRun {
+		report, err := newDryRunReport(requestCtx, &settings, plan)
+		if err != nil {
+			logger.Error(err.Error())
+			writeProblem(c, err)
+			return
+		}
+		plan.result.log(requestCtx)
+		logger.Info(c.Request.URL.Path + "[" + c.Request.Method + "] dry run completed successfully.")
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	// A targeted sync collects part of the devices only, so it would empty the quarantine and the inventory of the other ones
+	if !plan.result.Partial {
+		updateQuarantine(plan.timestamp, plan.invalidDevices)
+		previous := updateInventory(plan.collected)
+		updateClassification(plan, time.Now())
+		// The first snapshot is the baseline of the changes
+		if !previous.CollectedAt.IsZero() {
+			events := diffDevices(previous.Devices, plan.collected.Devices)
+			publishDeviceEvents(requestCtx, events, plan.timestamp, *settings.EventConfigs.MaxEvents)
+		}
+	}
+
+	// The tasks write their outcomes, which are read only once every task has returned
+	for _, alert := range plan.alerts {
+		logger.Warn(fmt.Sprintf("%s existed. Send an alert notification.", alert.name))
+	}
+	tasks, alertOutcomes := notificationTasks(&settings, plan.alerts)
+
+	// Forward the edited data to configuration-manager. Its outcome decides whether the sync succeeded.
+	var forwardOutcome deliveryOutcome
+	var failureOutcomes []deliveryOutcome
+	tasks = append(tasks, func(ctx context.Context) {
+		forwardOutcome = forwardData(ctx, httpClientFor(&settings.HttpClientConfigs), &plan.forward, plan.resources)
+		if forwardOutcome.Succeeded {
+			recordSyncSuccess()
+		} else {
+			failureOutcomes = recordSyncFailure(ctx, &settings, syncForwardFailure, "forward", errors.New(forwardOutcome.Error))
+		}
+		recordSyncRun(run.completed(plan.result, forwardOutcome))
+	})
+
+	done := runInBackground(requestCtx, deadline, tasks...)
+
+	if !wait {
+		plan.result.log(requestCtx)
+		logger.Info(c.Request.URL.Path + "[" + c.Request.Method + "] completed successfully.")
+		c.JSON(http.StatusAccepted, plan.result)
 		return
 	}
 
+	// The tasks are bound to the deadline of the sync, so the wait is bound to it as well
+	select {
+	case <-done:
+	case <-c.Request.Context().Done():
+		logger.Warn(c.Request.URL.Path + "[" + c.Request.Method + "] the caller went away. The sync continues in the background.")
+		return
+	case <-shutdownStarted():
+		// The server waits for the requests in progress, it would wait for the sync as well
+		plan.result.log(requestCtx)
+		logger.Warn(c.Request.URL.Path + "[" + c.Request.Method + "] the server is shutting down. The sync continues in the background.")
+		c.JSON(http.StatusAccepted, plan.result)
+		return
+	}
+
+	plan.result.Forward = &forwardOutcome
+	plan.result.Alerts = append(alertOutcomes, failureOutcomes...)
+	plan.result.DurationMs = time.Since(start).Milliseconds()
+	plan.result.log(requestCtx)
+
+	if !plan.result.succeeded() {
+		logger.Error(c.Request.URL.Path + "[" + c.Request.Method + "] completed with failures.")
+		c.JSON(http.StatusBadGateway, plan.result)
+		return
+	}
+	logger.Info(c.Request.URL.Path + "[" + c.Request.Method + "] completed successfully.")
+	c.JSON(http.StatusOK, plan.result)
+}
+
+// syncPlan is what a sync forwards and alerts on, decided from the collected devices
+type syncPlan struct {
+	result         syncResult
+	timestamp      string
+	invalidDevices []quarantinedDevice
+	// Output as collected, before the targeting, the filters and the quarantine
+	collected Output
+	// Entries of incompleteDeviceList kept by the filters, including the suppressed ones
+	incomplete []any
+	// Forwarded devices with an abnormal status and their evaluation, including the suppressed ones
+	abnormal []abnormalDevice
+	// Evaluation of the status of every forwarded device with an ID
+	evaluations map[string]statusEvaluation
+	// Edited data forwarded to configuration-manager
+	resources []any
+	// Forward settings, flagging the forward as partial for a targeted sync
+	forward yamlForwardConfig
+	// Alerts to notify, in order
+	alerts []plannedAlert
+}
+
+type plannedAlert struct {
+	name    string
+	entries []any
+}
+
+// planSync collects the devices and classifies them. A nil target synchronizes every device.
+// It has no side effect besides the collection, so that it can serve a dry run.
+func planSync(ctx context.Context, settings *yamlContent, target *syncTarget) (*syncPlan, error) {
+	// The device schema is loaded before collecting, so that a broken schema fails fast
+	schema, err := loadDeviceSchema(&settings.ValidationConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	collectSettings := *settings
+	collectSettings.CollectConfigs.TargetUrl = target.collectUrl(&settings.CollectConfigs)
+
+	output := Output{}
+	err = requestDevices(ctx, &collectSettings, &output)
+	if err != nil {
+		return nil, err
+	}
+	logFor(ctx).Debug(fmt.Sprintf("%d devices and %d incomplete entries were collected from %s. infoTimestamp: %s",
+		len(output.Devices), len(output.IncompleteDevices), collectSettings.CollectConfigs.TargetUrl, output.TimeStamp))
+
+	plan := &syncPlan{
+		result:    syncResult{CollectedDevices: len(output.Devices), IncompleteDevices: len(output.IncompleteDevices)},
+		timestamp: output.TimeStamp,
+		collected: output,
+		forward:   settings.ForwardConfigs,
+	}
+
+	// Keep the targeted devices only, hw-control may have returned others
+	if target != nil {
+		output.Devices, plan.result.UntargetedDevices = selectTargetDevices(ctx, target, output.Devices)
+		plan.result.Partial = true
+		plan.forward = partialForwardConfig(settings.ForwardConfigs)
+	}
+
+	// Remove the devices excluded by the filters first, they are neither quarantined, forwarded, classified nor alerted on
+	var filteredEntries int
+	output.Devices, plan.result.FilteredDevices = filterDevices(ctx, &settings.FilterConfigs, output.Devices)
+	output.IncompleteDevices, filteredEntries = filterIncompleteEntries(ctx, &settings.FilterConfigs, output.IncompleteDevices)
+	plan.result.FilteredDevices += filteredEntries
+	plan.incomplete = output.IncompleteDevices
+
+	// Quarantine the devices that do not conform to the device schema, they are neither forwarded nor classified
+	output.Devices, plan.invalidDevices = splitInvalidDevices(ctx, schema, output.Devices)
+	plan.result.QuarantinedDevices = len(plan.invalidDevices)
+
+	// The devices in a maintenance window are not alerted on, whatever the alert
+	maintenance := newMaintenanceSuppression(ctx, &settings.MaintenanceConfigs, settings.DeviceLabels, time.Now())
+
+	// If there are quarantined devices, notify the alert of invalidDeviceList when enabled
+	if len(plan.invalidDevices) > 0 && settings.ValidationConfigs.Alert {
+		if alerted := maintenance.alertedQuarantined(ctx, plan.invalidDevices); len(alerted) > 0 {
+			plan.alerts = append(plan.alerts, plannedAlert{invalidDeviceList, quarantineAlerts(alerted)})
+		}
+	} else if len(plan.invalidDevices) > 0 {
+		logFor(ctx).Warn(fmt.Sprintf("%s existed. The alert notification is disabled.", invalidDeviceList))
+	}
+
 	// If incompleteDeviceList exists, notify the alert of incompleteDeviceList
-	if output.IncompleteDevices != nil {
-		log.Warn(fmt.Sprintf("%s existed. Send an alert notification.", incompleteDeviceList))
-		go postAlert(incompleteDeviceList, output.IncompleteDevices, &settings)
+	if len(output.IncompleteDevices) > 0 {
+		if alerted := maintenance.alertedIncomplete(ctx, output.IncompleteDevices); len(alerted) > 0 {
+			plan.alerts = append(plan.alerts, plannedAlert{incompleteDeviceList, alerted})
+		}
 	} else {
-		log.Info(fmt.Sprintf("%s not existed. Not send an alert notification.", incompleteDeviceList))
+		logFor(ctx).Info(fmt.Sprintf("%s not existed. Not send an alert notification.", incompleteDeviceList))
 	}
 
 	// Edit the data obtained from bulk information retrieval of all HW control resources
-	// into the format of HW information synchronization input for configuration information management
-	resources := make([]any, 0)
-	abnormalResources := make([]any, 0)
-	for _, device := range output.Devices {
-		resources = append(resources, device)
-		if !isResourceStatus(device, settings.AlertConfigs.StateSettings) {
-			abnormalResources = append(abnormalResources, device)
+	// into the format of HW information synchronization input for configuration information management.
+	// The transform rules apply to the forwarded data only, the alerts carry the devices as collected.
+	_, span := tracer.Start(ctx, "classify")
+	plan.resources = make([]any, 0)
+	plan.abnormal = make([]abnormalDevice, 0)
+	plan.evaluations = make(map[string]statusEvaluation, len(output.Devices))
+	for i, device := range output.Devices {
+		plan.resources = append(plan.resources, transformDevice(ctx, settings.TransformConfigs.steps, device))
+		evaluation := evaluateStatus(device, settings.AlertConfigs.StateSettings)
+		if _, ok := plan.evaluations[device.ID]; !ok && device.ID != "" {
+			plan.evaluations[device.ID] = evaluation
+		}
+		if !evaluation.Normal {
+			logFor(ctx).Warn(fmt.Sprintf("device %s is abnormal. %s", deviceLabel(&output.Devices[i], i), evaluation.Message))
+			plan.abnormal = append(plan.abnormal, abnormalDevice{Device: device, Evaluation: evaluation})
+		} else {
+			logFor(ctx).Debug(fmt.Sprintf("device %s is normal.", deviceLabel(&output.Devices[i], i)))
 		}
 	}
 
+	plan.result.ForwardedDevices = len(plan.resources)
+	plan.result.AbnormalDevices = len(plan.abnormal)
+
+	// The abnormal devices in a maintenance window are forwarded, but not alerted on
+	alerted := maintenance.alertedAbnormal(ctx, plan.abnormal)
+	plan.result.Suppressed = maintenance.suppressed
+	plan.result.SuppressedDevices = len(plan.result.Suppressed)
+	span.SetAttributes(
+		attribute.Int("devices.forwarded", plan.result.ForwardedDevices),
+		attribute.Int("devices.abnormal", plan.result.AbnormalDevices),
+		attribute.Int("devices.suppressed", plan.result.SuppressedDevices),
+	)
+	span.End()
+
 	// If there are resources with abnormal status, notify the alert of abnormalStatusDeviceList
-	if len(abnormalResources) > 0 {
-		log.Warn(fmt.Sprintf("%s existed. Send an alert notification.", abnormalStatusDeviceList))
-		go postAlert(abnormalStatusDeviceList, abnormalResources, &settings)
+	if len(alerted) > 0 {
+		abnormalResources := make([]any, 0, len(alerted))
+		for _, device := range alerted {
+			abnormalResources = append(abnormalResources, device.alertEntry())
+		}
+		plan.alerts = append(plan.alerts, plannedAlert{abnormalStatusDeviceList, abnormalResources})
 	} else {
-		log.Info(fmt.Sprintf("%s not existed. Not send an alert notification.", abnormalStatusDeviceList))
+		logFor(ctx).Info(fmt.Sprintf("%s not existed. Not send an alert notification.", abnormalStatusDeviceList))
 	}
 
-	// Forward the edited data to configuration-manager.
-	go forwardData(&settings.ForwardConfigs, resources)
-
-	log.Info(c.Request.URL.Path + "[" + c.Request.Method + "] completed successfully.")
-	c.JSON(http.StatusAccepted, nil)
+	return plan, nil
 }
 
 // Load settings from yaml file and store in struct
 func loadConfig(filepath string, settings *yamlContent) error {
 	buf, err := os.ReadFile(filepath)
 	if err != nil {
-		return ExpErrorNew(http.StatusInternalServerError, "0001", "Failed to read file.")
+		return errReadFile.Wrap(err)
 	}
 
 	err = yaml.Unmarshal(buf, &settings)
 	if err != nil {
-		return ExpErrorNew(http.StatusInternalServerError, "0002", "Failed to unmarshal yaml.")
+		return errUnmarshalYaml.Wrap(err)
 	}
 
 	// Check the required and format of URL (collect_configs/target_url)
@@ -222,6 +521,102 @@ func loadConfig(filepath string, settings *yamlContent) error {
 		return err
 	}
 
+	// Check the range of the maximum response size (collect_configs/max_response_size)
+	if settings.CollectConfigs.MaxResponseSize == nil {
+		maxResponseSize := defaultMaxResponseSize
+		settings.CollectConfigs.MaxResponseSize = &maxResponseSize
+	} else if *settings.CollectConfigs.MaxResponseSize < 1 {
+		return errOutOfRange.New("collect_configs/max_response_size")
+	}
+
+	// Check the supported encodings (collect_configs/accept_encoding, forward_configs/content_encoding, alert_config/content_encoding)
+	err = validConfigEncodings("collect_configs/accept_encoding", settings.CollectConfigs.AcceptEncoding)
+	if err != nil {
+		return err
+	}
+
+	err = validConfigEncoding("forward_configs/content_encoding", settings.ForwardConfigs.ContentEncoding)
+	if err != nil {
+		return err
+	}
+
+	err = validConfigEncoding("alert_config/content_encoding", settings.AlertConfigs.ContentEncoding)
+	if err != nil {
+		return err
+	}
+
+	// Check the notification sinks (alert_config/kinds, notification_configs/sinks)
+	err = validNotificationConfig(&settings.NotificationConfigs, &settings.AlertConfigs)
+	if err != nil {
+		return err
+	}
+
+	// Check the logging settings (logging_configs)
+	err = validLoggingConfig(&settings.LoggingConfigs)
+	if err != nil {
+		return err
+	}
+
+	// Check the caller identification settings (audit_configs)
+	err = validAuditConfig(&settings.AuditConfigs)
+	if err != nil {
+		return err
+	}
+
+	// Check the run history settings (history_configs)
+	err = validHistoryConfig(&settings.HistoryConfigs)
+	if err != nil {
+		return err
+	}
+
+	// Check the device event settings (event_configs)
+	err = validEventConfig(&settings.EventConfigs)
+	if err != nil {
+		return err
+	}
+
+	// Check the tracing settings (tracing_configs)
+	err = validTracingConfig(&settings.TracingConfigs)
+	if err != nil {
+		return err
+	}
+
+	// Check the self-monitoring settings (monitoring_configs)
+	err = validMonitoringConfig(&settings.MonitoringConfigs)
+	if err != nil {
+		return err
+	}
+
+	// Check the range of Timeout (sync_configs/timeout)
+	settings.SyncConfigs.TimeOut, err = validConfigSyncTime("sync_configs/timeout", settings)
+	if err != nil {
+		return err
+	}
+
+	// Check the settings of the HTTP client shared by all targets (http_client_configs)
+	err = validHttpClientConfig(&settings.HttpClientConfigs)
+	if err != nil {
+		return err
+	}
+
+	// Check and compile the transform rules (transform_configs/rules)
+	err = validTransformConfig(&settings.TransformConfigs)
+	if err != nil {
+		return err
+	}
+
+	// Check and compile the device filters (filter_configs)
+	err = validFilterConfig(&settings.FilterConfigs, settings.DeviceLabels)
+	if err != nil {
+		return err
+	}
+
+	// Check the maintenance windows (maintenance_configs/windows)
+	err = validMaintenanceConfig(&settings.MaintenanceConfigs, settings.DeviceLabels)
+	if err != nil {
+		return err
+	}
+
 	// Check for nil or empty slice (alert_config/state_settings/normal_state)
 	err = validConfigSliceRequired("alert_config/state_settings/normal_state", settings.AlertConfigs.StateSettings.NormalState)
 	if err != nil {
@@ -240,13 +635,13 @@ func loadConfig(filepath string, settings *yamlContent) error {
 // Check for required and format of URL
 func validConfigUrl(targetName string, targetValue string) error {
 	if targetValue == "" {
-		return ExpErrorNew(http.StatusInternalServerError, "0010", fmt.Sprintf("%s setting is required.", targetName))
+		return errSettingRequired.New(targetName)
 	}
 	// url.Parse allows relative paths. url.ParseRequestURI only allows absolute URIs or absolute paths.
 	// Since we want an absolute URI here, we parse with url.ParseRequestURI.
 	_, err := url.ParseRequestURI(targetValue)
 	if err != nil {
-		return ExpErrorNew(http.StatusInternalServerError, "0011", fmt.Sprintf("%s Format of the url is invalid.", targetName))
+		return errUrlFormat.Wrap(err, targetName)
 	}
 
 	return nil
@@ -260,149 +655,231 @@ func validConfigTime(targetName string, targetValue *int) (*int, error) {
 		return &defTimeout, nil
 	}
 	if *targetValue < minTimeout || *targetValue > maxTimeout {
-		return nil, ExpErrorNew(http.StatusInternalServerError, "0012", fmt.Sprintf("%s value is out of range.", targetName))
+		return nil, errOutOfRange.New(targetName)
 	}
 
 	return targetValue, nil
 }
 
+// Check the range of the overall sync Timeout.
+// When it is omitted, the collection plus the longest of the forward and the notifications is allowed.
+func validConfigSyncTime(targetName string, settings *yamlContent) (*int, error) {
+	if settings.SyncConfigs.TimeOut == nil {
+		deliveryTimeout := max(*settings.ForwardConfigs.TimeOut, *settings.AlertConfigs.TimeOut)
+		for _, sink := range settings.NotificationConfigs.Sinks {
+			deliveryTimeout = max(deliveryTimeout, *sink.TimeOut)
+		}
+		syncTimeout := *settings.CollectConfigs.TimeOut + deliveryTimeout
+		return &syncTimeout, nil
+	}
+	if *settings.SyncConfigs.TimeOut < minTimeout || *settings.SyncConfigs.TimeOut > maxTimeout {
+		return nil, errOutOfRange.New(targetName)
+	}
+
+	return settings.SyncConfigs.TimeOut, nil
+}
+
 // Check for nil or empty slice
 func validConfigSliceRequired(targetName string, targetValue []string) error {
 	if targetValue == nil {
-		return ExpErrorNew(http.StatusInternalServerError, "0013", fmt.Sprintf("%s value is nil.", targetName))
+		return errValueNil.New(targetName)
 	}
 
 	if len(targetValue) == 0 {
-		return ExpErrorNew(http.StatusInternalServerError, "0014", fmt.Sprintf("%s value is blank.", targetName))
+		return errValueBlank.New(targetName)
 	}
 
 	return nil
 }
 
 // Request bulk information retrieval of all resources for HW control
-func requestDevices(settings *yamlContent, output *Output) error {
-	// Since http.Client does not have a timeout set by default, set it
-	httpClient := http.Client{Timeout: time.Duration(*settings.CollectConfigs.TimeOut) * time.Second}
+func requestDevices(ctx context.Context, settings *yamlContent, output *Output) (err error) {
+	ctx, span := startClientSpan(ctx, "collect", http.MethodGet, settings.CollectConfigs.TargetUrl)
+	start := time.Now()
+	statusCode := 0
+	defer func() {
+		endSpan(span, err)
+		logFor(ctx).event(logEvent{
+			Event:      "collect.completed",
+			Phase:      "collect",
+			Target:     redactUrl(settings.CollectConfigs.TargetUrl),
+			StatusCode: statusCode,
+			Succeeded:  err == nil,
+			Devices:    map[string]int{"collected": len(output.Devices), "incomplete": len(output.IncompleteDevices)},
+			DurationMs: time.Since(start).Milliseconds(),
+		}.withError(err))
+	}()
+
+	ctx, cancel := withTimeout(ctx, settings.CollectConfigs.TimeOut)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, settings.CollectConfigs.TargetUrl, nil)
+	if err != nil {
+		return errGetRequest.Wrap(err)
+	}
+	setRequestIdHeader(ctx, req)
+	injectTraceContext(ctx, req)
+	// Setting Accept-Encoding turns off the transparent gzip of the transport, the body is decoded below instead
+	if len(settings.CollectConfigs.AcceptEncoding) > 0 {
+		req.Header.Set("Accept-Encoding", acceptEncodingHeader(settings.CollectConfigs.AcceptEncoding))
+	}
 
-	resp, err := httpClient.Get(settings.CollectConfigs.TargetUrl)
+	resp, err := httpClientFor(&settings.HttpClientConfigs).Do(req)
 	if err != nil {
-		return ExpErrorNew(http.StatusInternalServerError, "0006", "Get request failure.")
+		return contextError(ctx, errGetRequest.Wrap(err))
 	}
 	defer resp.Body.Close()
 
+	statusCode = resp.StatusCode
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 	if resp.StatusCode != http.StatusOK {
-		return ExpErrorNew(http.StatusInternalServerError, "0007", "Collect target failure.")
+		return errCollectTarget.New()
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return ExpErrorNew(http.StatusInternalServerError, "0008", "Failed to read response.")
+	maxResponseSize := *orDefault64(settings.CollectConfigs.MaxResponseSize, defaultMaxResponseSize)
+	if resp.ContentLength > maxResponseSize {
+		return errResponseSize.New()
 	}
 
-	err = json.Unmarshal(body, &output)
+	body, err := decodeResponseBody(resp)
 	if err != nil {
-		return ExpErrorNew(http.StatusInternalServerError, "0009", "Failed to unmarshal response.")
+		return errDecompress.Wrap(err)
+	}
+	defer body.Close()
+
+	// Decode the response while reading it, instead of reading it all before unmarshaling.
+	// The size is limited after decompression.
+	err = decodeOutput(&sizeLimitedReader{r: body, limit: maxResponseSize}, output)
+	switch {
+	case errors.Is(err, errResponseTooLarge):
+		return errResponseSize.New()
+	case err != nil && ctx.Err() != nil:
+		return contextError(ctx, errReadResponse.Wrap(err))
+	case err != nil:
+		return errUnmarshalResponse.Wrap(err)
 	}
 
 	return nil
 }
 
-// Return true if the resource status is normal, false if abnormal
-func isResourceStatus(resource map[string]any, stateSetting yamlStateSetting) bool {
-	status, ok := resource["status"].(map[string]any)
-	if !ok {
-		log.Warn("status does not exist or the value is not a Map.")
-		return false
+// Evaluate whether the resource status is normal, and which rule decided it
+func evaluateStatus(device Device, stateSetting yamlStateSetting) statusEvaluation {
+	if device.Status == nil {
+		return statusEvaluation{
+			Rule:     ruleStatusRequired,
+			Field:    "status",
+			Observed: device.Raw["status"],
+			Message:  "status does not exist or the value is not a Map.",
+		}
 	}
+	rawStatus, _ := device.Raw["status"].(map[string]any)
 
-	ok = isResourceStatusOne("state", status, stateSetting.NormalState)
-	if !ok {
-		return false
+	evaluation := evaluateStatusOne("state", device.Status.State, rawStatus["state"], stateSetting.NormalState)
+	if !evaluation.Normal {
+		return evaluation
 	}
 
-	ok = isResourceStatusOne("health", status, stateSetting.NormalHealth)
-	if !ok {
-		return false
+	evaluation = evaluateStatusOne("health", device.Status.Health, rawStatus["health"], stateSetting.NormalHealth)
+	if !evaluation.Normal {
+		return evaluation
 	}
 
-	return true
+	return statusEvaluation{Normal: true, Rule: ruleNormal, Message: "status.state and status.health are normal."}
 }
 
-// Return true if the value of the resource's state or health element is normal, false if abnormal
-func isResourceStatusOne(key string, statusMap map[string]any, normalStatusList []string) bool {
-	status, ok := statusMap[key].(string)
-	if !ok {
-		log.Warn(fmt.Sprintf("status.%s does not exist or the value is not a String.", key))
-		return false
+// Evaluate whether the value of the resource's state or health element is normal.
+// observed is the value as collected, which may not be a string.
+func evaluateStatusOne(key string, status string, observed any, normalStatusList []string) statusEvaluation {
+	evaluation := statusEvaluation{Field: "status." + key, Observed: observed, Expected: normalStatusList}
+
+	if status == "" {
+		evaluation.Rule = ruleValueRequired
+		evaluation.Message = fmt.Sprintf("status.%s does not exist or the value is not a String.", key)
+		return evaluation
 	}
 
-	return slices.Contains(normalStatusList, status)
+	if !slices.Contains(normalStatusList, status) {
+		evaluation.Rule = ruleNormalValue
+		evaluation.Message = fmt.Sprintf("status.%s %s is not one of alert_config/state_settings/normal_%s %v.", key, status, key, normalStatusList)
+		return evaluation
+	}
+
+	return statusEvaluation{Normal: true, Rule: ruleNormalValue}
 }
 
-// POST an alert to the alert notification destination
-func postAlert(alertName string, alerts []any, settings *yamlContent) {
-	log.Info("Starting the post.")
+// POST an alert to the alert notification destination (alert_config) and return the outcome
+func postAlert(ctx context.Context, alertName string, alerts []any, settings *yamlContent) deliveryOutcome {
+	return notify(ctx, httpClientFor(&settings.HttpClientConfigs), alertConfigSink(&settings.AlertConfigs), plannedAlert{alertName, alerts})
+}
 
-	// Marshal the alerts to set the result as a string in "annotations"
+// marshalAlert returns the body of the alert, with the entries set as a string in "annotations"
+// The error is logged by the caller, with the delivery of the alert.
+func marshalAlert(alertName string, alerts []any) ([]byte, error) {
 	annotationsJson, err := json.Marshal(alerts)
 	if err != nil {
-		log.Error("Failed to marshal for 'annotations'.")
-		log.Error(fmt.Sprintf("Unmarshalable: %#v", alerts), false)
-		log.Error(err.Error(), false)
-		return
+		return nil, err
 	}
 
 	alertBody := NewAlertContentList(alertName, "critical", string(annotationsJson))
 
 	alertJsonBody, err := json.Marshal(alertBody)
 	if err != nil {
-		log.Error("Failed to marshal.")
-		log.Error(fmt.Sprintf("Unmarshalable: %#v", alertBody), false)
-		log.Error(err.Error(), false)
-		return
+		return nil, err
 	}
 
-	httpClient := http.Client{Timeout: time.Duration(*settings.AlertConfigs.TimeOut) * time.Second}
-	_, err = httpClient.Post(settings.AlertConfigs.TargetUrl, "application/json", bytes.NewBuffer(alertJsonBody))
-	if err != nil {
-		log.Error("post has failed.")
-		log.Error(string(alertJsonBody), false)
-		log.Error(err.Error(), false)
-		return
-	}
-
-	log.Info("post has been completed.")
-	log.Info(string(alertJsonBody))
-
-	return
+	return alertJsonBody, nil
 }
 
 // forwardData sends the provided data to a specified target URL using an HTTP POST request.
-// This function is called asynchronously, so it does not return an error.
+// This function is called asynchronously, so it does not return an error but the outcome of the forward.
 //
 // Parameters:
+//   - ctx: The context bounding the forward. forward_configs/timeout is applied on top of it.
+//   - httpClient: The shared HTTP client.
 //   - settings: A pointer to a yamlForwardConfig struct.
-//   - data: The resource data to be sent, which can be of any type.
-func forwardData(settings *yamlForwardConfig, data any) {
-	jsonData, err := json.Marshal(data)
+//   - resources: The resource data to be sent. It is encoded while it is being sent.
+func forwardData(ctx context.Context, httpClient *http.Client, settings *yamlForwardConfig, resources []any) (result deliveryOutcome) {
+	ctx, span := startClientSpan(ctx, "forward", http.MethodPost, settings.TargetUrl, attribute.Int("forward.resources", len(resources)))
+	defer func() {
+		endDeliverySpan(span, result)
+		event := deliveryEvent("forward", settings.TargetUrl, result)
+		event.Devices = map[string]int{"forwarded": len(resources)}
+		logFor(ctx).event(event)
+	}()
+
+	outcome := startDelivery("")
+	ctx, cancel := withTimeout(ctx, settings.TimeOut)
+	defer cancel()
+
+	// Stream the body to the request instead of marshaling it all at once
+	body, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(encodeCompressedResources(writer, resources, settings.ContentEncoding))
+	}()
+	defer body.Close()
+
+	res, err := postJson(ctx, httpClient, settings.TargetUrl, body, settings.ContentEncoding)
 	if err != nil {
-		log.Error(err.Error())
-		return
+		return outcome.failed(err)
 	}
+	defer res.Body.Close()
+
+	return outcome.completed(res.StatusCode, res.StatusCode == http.StatusCreated)
+}
 
-	httpClient := http.Client{Timeout: time.Duration(*settings.TimeOut) * time.Second}
-	res, err := httpClient.Post(settings.TargetUrl, "application/json", bytes.NewBuffer(jsonData))
+// postJson POSTs the JSON body to the target URL within the given context.
+// contentEncoding is the encoding the body is compressed with, or empty when it is not compressed.
+func postJson(ctx context.Context, httpClient *http.Client, targetUrl string, body io.Reader, contentEncoding string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetUrl, body)
 	if err != nil {
-		log.Error(err.Error())
-		return
+		return nil, err
 	}
-
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusCreated {
-		statusCode := strconv.Itoa(res.StatusCode)
-		log.Error("status code = " + statusCode)
-		return
+	req.Header.Set("Content-Type", "application/json")
+	setRequestIdHeader(ctx, req)
+	injectTraceContext(ctx, req)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
 	}
 
-	return
+	return httpClient.Do(req)
 }
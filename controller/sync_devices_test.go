@@ -15,15 +15,65 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Point the settings of SyncDevices at the test targets
+func useSyncConfig(t *testing.T, collectUrl, forwardUrl, alertUrl string) {
+	content := fmt.Sprintf(`collect_configs:
+  target_url: '%s'
+  timeout: 10
+forward_configs:
+  target_url: '%s'
+  timeout: 10
+alert_config:
+  target_url: '%s'
+  timeout: 10
+  state_settings:
+    normal_state: ['Enabled']
+    normal_health: ['OK']
+`, collectUrl, forwardUrl, alertUrl)
+	path := filepath.Join(t.TempDir(), "exporter.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	previous := yamlFilePath
+	yamlFilePath = path
+	t.Cleanup(func() {
+		inflight.Wait()
+		yamlFilePath = previous
+		syncHealth.Lock()
+		syncHealth.lastSettings = nil
+		syncHealth.Unlock()
+	})
+}
+
+// Start a target counting the requests it receives and answering them with the status
+func newCountingServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		received.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
 func TestSyncDevices(t *testing.T) {
 	w := httptest.NewRecorder()
 	// Create gin context
@@ -299,6 +349,33 @@ func Test_loadConfig(t *testing.T) {
 			"",
 			true,
 		},
+		{
+			"Error case: sync_configs/timeout is less than the lower limit. Boundary value test",
+			args{
+				"testdata/sync_timeout0.yaml",
+				settings,
+			},
+			"",
+			true,
+		},
+		{
+			"Normal case: sync_configs/timeout is equal to the upper limit. Boundary value test",
+			args{
+				"testdata/sync_timeout36000.yaml",
+				settings,
+			},
+			"",
+			false,
+		},
+		{
+			"Error case: sync_configs/timeout is greater than the upper limit. Boundary value test",
+			args{
+				"testdata/sync_timeout36001.yaml",
+				settings,
+			},
+			"",
+			true,
+		},
 		{
 			"Normal case: Typical usage scenario",
 			args{
@@ -338,130 +415,225 @@ func Test_validConfigSliceRequired(t *testing.T) {
 }
 
 func Test_requestDevices(t *testing.T) {
-	t.Skip("not test")
+	testServerOk := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"deviceList": [{"deviceID": "dev1"}], "infoTimestamp": "2025-01-01T00:00:00Z"}`))
+	}))
+	defer testServerOk.Close()
+
+	testServerNg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer testServerNg.Close()
+
+	testServerText := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`text`))
+	}))
+	defer testServerText.Close()
+
+	testServerSlow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer testServerSlow.Close()
+
+	timeout := 600
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expiredCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		url             string
+		maxResponseSize int64
+		wantCode        string
+	}{
+		{"Normal case: Devices are collected", context.Background(), testServerOk.URL, defaultMaxResponseSize, ""},
+		{"Error case: Collect target returns an error status", context.Background(), testServerNg.URL, defaultMaxResponseSize, "0007"},
+		{"Error case: Response is not in JSON format", context.Background(), testServerText.URL, defaultMaxResponseSize, "0009"},
+		{"Error case: Response exceeds the maximum size", context.Background(), testServerOk.URL, 10, "0017"},
+		{"Error case: Sync deadline is exceeded during the collection", expiredCtx, testServerSlow.URL, defaultMaxResponseSize, "0015"},
+		{"Error case: Sync is cancelled before the collection", cancelledCtx, testServerOk.URL, defaultMaxResponseSize, "0016"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := yamlContent{CollectConfigs: yamlCollectConfig{TargetUrl: tt.url, TimeOut: &timeout, MaxResponseSize: &tt.maxResponseSize}}
+			output := Output{}
+			err := requestDevices(tt.ctx, &settings, &output)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Errorf("requestDevices() error = %v", err)
+				}
+				return
+			}
+			expErr, ok := err.(*ExpError)
+			if !ok || expErr.Code != tt.wantCode {
+				t.Errorf("requestDevices() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
 }
 
-func Test_isResourceStatus(t *testing.T) {
+func Test_evaluateStatus(t *testing.T) {
 	type args struct {
-		resource     map[string]any
+		device       Device
 		stateSetting yamlStateSetting
 	}
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name         string
+		args         args
+		want         bool
+		wantRule     string
+		wantField    string
+		wantObserved any
 	}{
 		{
 			"Error case: Resource without status element",
 			args{
-				map[string]any{"test": "aaa"},
+				newDevice(map[string]any{"test": "aaa"}),
 				yamlStateSetting{
 					NormalState:  []string{"Enabled", "Qualified"},
 					NormalHealth: []string{"OK", "Warning"},
 				},
 			},
 			false,
+			ruleStatusRequired,
+			"status",
+			nil,
 		},
 		{
 			"Error case: Resource where the value of the status element is not a map",
 			args{
-				map[string]any{"status": "aaa"},
+				newDevice(map[string]any{"status": "aaa"}),
 				yamlStateSetting{
 					NormalState:  []string{"Enabled", "Qualified"},
 					NormalHealth: []string{"OK", "Warning"},
 				},
 			},
 			false,
+			ruleStatusRequired,
+			"status",
+			"aaa",
 		},
 		{
 			"Error case: Resource without status.state element",
 			args{
-				map[string]any{"status": map[string]any{"test": "Enabled", "health": "OK"}},
+				newDevice(map[string]any{"status": map[string]any{"test": "Enabled", "health": "OK"}}),
 				yamlStateSetting{
 					NormalState:  []string{"Enabled", "Qualified"},
 					NormalHealth: []string{"OK", "Warning"},
 				},
 			},
 			false,
+			ruleValueRequired,
+			"status.state",
+			nil,
 		},
 		{
 			"Error case: Resource where the value of the status.state element is not a string",
 			args{
-				map[string]any{"status": map[string]any{"state": 1, "health": "OK"}},
+				newDevice(map[string]any{"status": map[string]any{"state": 1, "health": "OK"}}),
 				yamlStateSetting{
 					NormalState:  []string{"Enabled", "Qualified"},
 					NormalHealth: []string{"OK", "Warning"},
 				},
 			},
 			false,
+			ruleValueRequired,
+			"status.state",
+			1,
 		},
 		{
 			"Error case: Resource where the value of status.state element is an abnormal value (not present in yamlStateSetting.NormalState)",
 			args{
-				map[string]any{"status": map[string]any{"state": "aaa", "health": "OK"}},
+				newDevice(map[string]any{"status": map[string]any{"state": "aaa", "health": "OK"}}),
 				yamlStateSetting{
 					NormalState:  []string{"Enabled", "Qualified"},
 					NormalHealth: []string{"OK", "Warning"},
 				},
 			},
 			false,
+			ruleNormalValue,
+			"status.state",
+			"aaa",
 		},
 		{
 			"Error case: Resource without status.health element",
 			args{
-				map[string]any{"status": map[string]any{"state": "Enabled", "test": "OK"}},
+				newDevice(map[string]any{"status": map[string]any{"state": "Enabled", "test": "OK"}}),
 				yamlStateSetting{
 					NormalState:  []string{"Enabled", "Qualified"},
 					NormalHealth: []string{"OK", "Warning"},
 				},
 			},
 			false,
+			ruleValueRequired,
+			"status.health",
+			nil,
 		},
 		{
 			"Error case: Resource where the value of status.health element is not a string",
 			args{
-				map[string]any{"status": map[string]any{"state": "Enabled", "health": 1}},
+				newDevice(map[string]any{"status": map[string]any{"state": "Enabled", "health": 1}}),
 				yamlStateSetting{
 					NormalState:  []string{"Enabled", "Qualified"},
 					NormalHealth: []string{"OK", "Warning"},
 				},
 			},
 			false,
+			ruleValueRequired,
+			"status.health",
+			1,
 		},
 		{
 			"Error case: Resource where the value of status.health element is an abnormal value (not present in yamlStateSetting.NormalHealth)",
 			args{
-				map[string]any{"status": map[string]any{"state": "Enabled", "health": "aaa"}},
+				newDevice(map[string]any{"status": map[string]any{"state": "Enabled", "health": "aaa"}}),
 				yamlStateSetting{
 					NormalState:  []string{"Enabled", "Qualified"},
 					NormalHealth: []string{"OK", "Warning"},
 				},
 			},
 			false,
+			ruleNormalValue,
+			"status.health",
+			"aaa",
 		},
 		{
 			"Normal case: Resource with normal status",
 			args{
-				map[string]any{"status": map[string]any{"state": "Enabled", "health": "OK"}},
+				newDevice(map[string]any{"status": map[string]any{"state": "Enabled", "health": "OK"}}),
 				yamlStateSetting{
 					NormalState:  []string{"Enabled", "Qualified"},
 					NormalHealth: []string{"OK", "Warning"},
 				},
 			},
 			true,
+			ruleNormal,
+			"",
+			nil,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isResourceStatus(tt.args.resource, tt.args.stateSetting); got != tt.want {
-				t.Errorf("isResourceStatus() = %v, want %v", got, tt.want)
+			got := evaluateStatus(tt.args.device, tt.args.stateSetting)
+			if got.Normal != tt.want || got.Rule != tt.wantRule || got.Field != tt.wantField || got.Observed != tt.wantObserved {
+				t.Errorf("evaluateStatus() = %+v, want normal %v, rule %s, field %s, observed %v", got, tt.want, tt.wantRule, tt.wantField, tt.wantObserved)
+			}
+			if got.Message == "" {
+				t.Errorf("evaluateStatus() message = %q", got.Message)
 			}
 		})
 	}
 }
 
-func Test_isResourceStatusOne(t *testing.T) {
-	t.Skip("not test. Because the isResourceStatus function is covered in the tests for this function.")
+func Test_evaluateStatusOne(t *testing.T) {
+	t.Skip("not test. Because the evaluateStatus function is covered in the tests for this function.")
 }
 
 func Test_postAlert(t *testing.T) {
@@ -484,8 +656,9 @@ func Test_postAlert(t *testing.T) {
 		settings  yamlContent
 	}
 	tests := []struct {
-		name string
-		args args
+		name    string
+		args    args
+		wantErr bool
 	}{
 		{
 			"Normal case: Post alert successfully",
@@ -499,6 +672,7 @@ func Test_postAlert(t *testing.T) {
 					},
 				},
 			},
+			false,
 		},
 		{
 			"Error case: Failed to marshal alerts",
@@ -512,6 +686,7 @@ func Test_postAlert(t *testing.T) {
 					},
 				},
 			},
+			true,
 		},
 		{
 			"Error case: Invalid alert target URL",
@@ -525,11 +700,15 @@ func Test_postAlert(t *testing.T) {
 					},
 				},
 			},
+			true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			postAlert(tt.args.alertName, tt.args.alerts, &tt.args.settings)
+			got := postAlert(context.Background(), tt.args.alertName, tt.args.alerts, &tt.args.settings)
+			if got.Succeeded == tt.wantErr || got.AlertName != tt.args.alertName {
+				t.Errorf("postAlert() outcome = %+v, wantErr %v", got, tt.wantErr)
+			}
 		})
 	}
 }
@@ -549,8 +728,8 @@ func Test_forwardData(t *testing.T) {
 	defer testServerError.Close()
 
 	type args struct {
-		settings yamlForwardConfig
-		data     any
+		settings  yamlForwardConfig
+		resources []any
 	}
 	tests := []struct {
 		name    string
@@ -564,7 +743,7 @@ func Test_forwardData(t *testing.T) {
 					TargetUrl: testServerCreated.URL,
 					TimeOut:   new(int),
 				},
-				data: map[string]string{"key": "value"},
+				resources: []any{map[string]string{"key": "value"}},
 			},
 			false,
 		},
@@ -575,7 +754,7 @@ func Test_forwardData(t *testing.T) {
 					TargetUrl: testServerCreated.URL,
 					TimeOut:   new(int),
 				},
-				data: make(chan int), // Unmarshalable type
+				resources: []any{make(chan int)}, // Unmarshalable type
 			},
 			true,
 		},
@@ -586,7 +765,7 @@ func Test_forwardData(t *testing.T) {
 					TargetUrl: "http://invalid-url",
 					TimeOut:   new(int),
 				},
-				data: map[string]string{"key": "value"},
+				resources: []any{map[string]string{"key": "value"}},
 			},
 			true,
 		},
@@ -597,14 +776,149 @@ func Test_forwardData(t *testing.T) {
 					TargetUrl: testServerError.URL,
 					TimeOut:   new(int),
 				},
-				data: map[string]string{"key": "value"},
+				resources: []any{map[string]string{"key": "value"}},
 			},
 			true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			forwardData(&tt.args.settings, tt.args.data)
+			got := forwardData(context.Background(), httpClientFor(&yamlHttpClientConfig{}), &tt.args.settings, tt.args.resources)
+			if got.Succeeded == tt.wantErr {
+				t.Errorf("forwardData() outcome = %+v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSyncDevices_callerGoneAway(t *testing.T) {
+	resetSyncHistory(t)
+	recordSyncSuccess()
+
+	// The collection is held until the caller goes away
+	var once sync.Once
+	collecting := make(chan struct{})
+	collect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(collecting) })
+		<-r.Context().Done()
+	}))
+	defer collect.Close()
+	target, received := newCountingServer(t, http.StatusOK)
+	useSyncConfig(t, collect.URL, target.URL, target.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-collecting
+		cancel()
+	}()
+
+	ginContext, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginContext.Request = httptest.NewRequest(http.MethodPost, "/cdim/api/v1/devices/sync", nil).WithContext(ctx)
+	SyncDevices(ginContext)
+	inflight.Wait()
+
+	if got := received.Load(); got != 0 {
+		t.Errorf("SyncDevices() sent %d alerts, want none", got)
+	}
+	if page := queryHistory(historyQuery{limit: maxHistoryLimit}); page.Total != 0 {
+		t.Errorf("SyncDevices() recorded %+v, want no run", page.Runs)
+	}
+	syncHealth.Lock()
+	failures := syncHealth.consecutiveFailures
+	syncHealth.Unlock()
+	if failures != 0 {
+		t.Errorf("SyncDevices() counted %d failures, want none", failures)
+	}
+}
+
+// Start a collection target returning a normal device
+func newCollectServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"deviceList": [{"deviceID": "dev1", "type": "CPU", "status": {"state": "Enabled", "health": "OK"}}], "infoTimestamp": "2025-01-01T00:00:00Z"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSyncDevices_wait(t *testing.T) {
+	tests := []struct {
+		name          string
+		forwardStatus int
+		wantStatus    int
+		wantSucceeded bool
+	}{
+		{"Normal case: Forward succeeded", http.StatusCreated, http.StatusOK, true},
+		{"Error case: Forward failed", http.StatusInternalServerError, http.StatusBadGateway, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetSyncHistory(t)
+			forward, forwarded := newCountingServer(t, tt.forwardStatus)
+			alert, _ := newCountingServer(t, http.StatusOK)
+			useSyncConfig(t, newCollectServer(t).URL, forward.URL, alert.URL)
+
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodPost, "/cdim/api/v1/devices/sync?wait=true", nil)
+			SyncDevices(ginContext)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("SyncDevices() status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var got syncResult
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("SyncDevices() body is not a result: %v", err)
+			}
+			if got.Forward == nil || got.Forward.Succeeded != tt.wantSucceeded || got.Forward.StatusCode != tt.forwardStatus || forwarded.Load() != 1 {
+				t.Errorf("SyncDevices() forward = %+v, want status %d", got.Forward, tt.forwardStatus)
+			}
+			if got.ForwardedDevices != 1 {
+				t.Errorf("SyncDevices() forwarded devices = %d, want 1", got.ForwardedDevices)
+			}
 		})
 	}
 }
+
+func TestSyncDevices_waitShutdown(t *testing.T) {
+	resetSyncHistory(t)
+	t.Cleanup(func() {
+		shuttingDown.Lock()
+		shuttingDown.ch, shuttingDown.closed = nil, false
+		shuttingDown.Unlock()
+	})
+
+	// The forward is held until the response has been returned
+	forwarding := make(chan struct{})
+	release := make(chan struct{})
+	forward := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(forwarding)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer forward.Close()
+	alert, _ := newCountingServer(t, http.StatusOK)
+	useSyncConfig(t, newCollectServer(t).URL, forward.URL, alert.URL)
+
+	go func() {
+		<-forwarding
+		BeginShutdown()
+	}()
+
+	w := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(w)
+	ginContext.Request = httptest.NewRequest(http.MethodPost, "/cdim/api/v1/devices/sync?wait=true", nil)
+	SyncDevices(ginContext)
+	close(release)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("SyncDevices() status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+
+	// The sync continues in the background and is recorded once the forward has finished
+	inflight.Wait()
+	if page := queryHistory(historyQuery{limit: maxHistoryLimit}); page.Total != 1 || page.Runs[0].Outcome != runSucceeded {
+		t.Errorf("SyncDevices() recorded %+v, want a succeeded run", page.Runs)
+	}
+}
@@ -0,0 +1,365 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Outcomes of a sync run
+const (
+	runSucceeded string = "succeeded"
+	runFailed    string = "failed"
+)
+
+var runOutcomes = []string{runSucceeded, runFailed}
+
+// Triggers of a sync run
+const triggerApi string = "api"
+
+const (
+	defaultMaxRuns      int = 1000
+	maxRunsLimit        int = 100000
+	defaultHistoryLimit int = 50
+	maxHistoryLimit     int = 1000
+)
+
+// The runs of the syncs are kept in memory and, when file is set, appended to it as JSON lines,
+// so that they survive a restart. The file is read when it is first set.
+//
+//   - file:     path of the history file, the history is in memory only when omitted.
+//   - max_runs: number of runs kept, the oldest are dropped.
+type yamlHistoryConfig struct {
+	File    string `yaml:"file"`
+	MaxRuns *int   `yaml:"max_runs"`
+}
+
+// syncRun is an entry of the run history
+type syncRun struct {
+	// ID of the request that started the sync
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+	Trigger   string    `json:"trigger"`
+	// User of the caller that triggered the sync
+	TriggeredBy string `json:"triggeredBy"`
+	Partial     bool   `json:"partial,omitempty"`
+	Outcome     string `json:"outcome"`
+	// Phase that failed: config, collect or forward
+	FailedPhase        string `json:"failedPhase,omitempty"`
+	ErrorCode          string `json:"errorCode,omitempty"`
+	Error              string `json:"error,omitempty"`
+	CollectedDevices   int    `json:"collectedDevices"`
+	ForwardedDevices   int    `json:"forwardedDevices"`
+	AbnormalDevices    int    `json:"abnormalDevices"`
+	IncompleteDevices  int    `json:"incompleteDevices"`
+	QuarantinedDevices int    `json:"quarantinedDevices"`
+	// Status code of the forward, 0 when it did not receive a response
+	ForwardStatus int `json:"forwardStatus,omitempty"`
+}
+
+// syncHistoryPage is a page of the run history, the newest run first
+type syncHistoryPage struct {
+	// Runs matching the filters
+	Total  int       `json:"total"`
+	Offset int       `json:"offset"`
+	Limit  int       `json:"limit"`
+	Runs   []syncRun `json:"runs"`
+}
+
+// The run history, oldest run first
+var syncHistory struct {
+	sync.Mutex
+	file    string
+	maxRuns int
+	runs    []syncRun
+	// Lines in the file, which is compacted when they are twice as many as the runs kept
+	fileLines int
+}
+
+// Check the history_configs settings and fill in the defaults
+func validHistoryConfig(settings *yamlHistoryConfig) error {
+	var err error
+	settings.MaxRuns, err = validConfigCount("history_configs/max_runs", settings.MaxRuns, defaultMaxRuns, maxRunsLimit)
+	if err != nil {
+		return err
+	}
+	if *settings.MaxRuns == 0 {
+		return errOutOfRange.New("history_configs/max_runs")
+	}
+	return nil
+}
+
+// applyHistoryConfig makes the history settings apply to the following runs.
+// A new history file is read, the runs in memory are replaced with its runs.
+func applyHistoryConfig(settings *yamlHistoryConfig) {
+	syncHistory.Lock()
+	defer syncHistory.Unlock()
+
+	syncHistory.maxRuns = *settings.MaxRuns
+	if settings.File != syncHistory.file {
+		syncHistory.file = settings.File
+		syncHistory.runs = nil
+		syncHistory.fileLines = 0
+		if settings.File != "" {
+			runs, lines, err := readHistoryFile(settings.File)
+			if err != nil {
+				log.Error(fmt.Sprintf("Failed to read the history file %s. %s", settings.File, err.Error()))
+			}
+			syncHistory.runs, syncHistory.fileLines = runs, lines
+		}
+	}
+	if len(syncHistory.runs) > syncHistory.maxRuns {
+		syncHistory.runs = slices.Clone(syncHistory.runs[len(syncHistory.runs)-syncHistory.maxRuns:])
+	}
+}
+
+// Read the runs of a history file, a missing file is an empty history. Lines that cannot be read are skipped.
+func readHistoryFile(path string) ([]syncRun, int, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	runs := make([]syncRun, 0)
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines++
+		var run syncRun
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			log.Warn(fmt.Sprintf("Line %d of the history file %s is skipped. %s", lines, path, err.Error()))
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, lines, scanner.Err()
+}
+
+// recordSyncRun adds the run to the history and appends it to the history file
+func recordSyncRun(run syncRun) {
+	syncHistory.Lock()
+	defer syncHistory.Unlock()
+
+	maxRuns := syncHistory.maxRuns
+	if maxRuns == 0 {
+		maxRuns = defaultMaxRuns
+	}
+	syncHistory.runs = append(syncHistory.runs, run)
+	if len(syncHistory.runs) > maxRuns {
+		syncHistory.runs = slices.Clone(syncHistory.runs[len(syncHistory.runs)-maxRuns:])
+	}
+
+	if syncHistory.file == "" {
+		return
+	}
+	var err error
+	if syncHistory.fileLines+1 > 2*maxRuns {
+		err = writeHistoryFile(syncHistory.file, syncHistory.runs)
+		syncHistory.fileLines = len(syncHistory.runs)
+	} else {
+		err = appendHistoryFile(syncHistory.file, run)
+		syncHistory.fileLines++
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to write the history file %s. %s", syncHistory.file, err.Error()))
+	}
+}
+
+// Append a run to the history file
+func appendHistoryFile(path string, run syncRun) error {
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	return errors.Join(err, file.Close())
+}
+
+// Replace the history file with the runs, through a temporary file so that it is never left half written
+func writeHistoryFile(path string, runs []syncRun) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	writer := bufio.NewWriter(temp)
+	enc := json.NewEncoder(writer)
+	for _, run := range runs {
+		if err := enc.Encode(run); err != nil {
+			temp.Close()
+			return err
+		}
+	}
+	if err := errors.Join(writer.Flush(), temp.Close()); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// newSyncRun starts the run of the sync requested by c
+func newSyncRun(c *gin.Context, start time.Time) syncRun {
+	return syncRun{ID: RequestIdOf(c), StartedAt: start.UTC(), Trigger: triggerApi, TriggeredBy: CallerOf(c).User}
+}
+
+// failed returns the run that failed in the phase, before the forward
+func (run syncRun) failed(phase string, err error) syncRun {
+	run.EndedAt = time.Now().UTC()
+	run.Outcome = runFailed
+	run.FailedPhase = phase
+	expErr := asExpError(err)
+	run.ErrorCode = expErr.Code
+	run.Error = expErr.Message
+	return run
+}
+
+// completed returns the run with the counts of the sync and the outcome of its forward
+func (run syncRun) completed(result syncResult, forward deliveryOutcome) syncRun {
+	run.EndedAt = time.Now().UTC()
+	run.Partial = result.Partial
+	run.CollectedDevices = result.CollectedDevices
+	run.ForwardedDevices = result.ForwardedDevices
+	run.AbnormalDevices = result.AbnormalDevices
+	run.IncompleteDevices = result.IncompleteDevices
+	run.QuarantinedDevices = result.QuarantinedDevices
+	run.ForwardStatus = forward.StatusCode
+	run.Outcome = runSucceeded
+	if !forward.Succeeded {
+		run.Outcome = runFailed
+		run.FailedPhase = "forward"
+		run.Error = forward.Error
+	}
+	return run
+}
+
+// historyQuery filters and pages the run history
+type historyQuery struct {
+	from    time.Time
+	to      time.Time
+	outcome string
+	offset  int
+	limit   int
+}
+
+// Read the query parameters of the history API
+func parseHistoryQuery(c *gin.Context) (historyQuery, error) {
+	query := historyQuery{limit: defaultHistoryLimit}
+	var err error
+
+	for name, value := range map[string]*time.Time{"from": &query.from, "to": &query.to} {
+		if raw := c.Query(name); raw != "" {
+			*value, err = time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return query, errHistoryQuery.Wrap(err, name, "It must be an RFC 3339 time.")
+			}
+		}
+	}
+
+	query.outcome = c.Query("outcome")
+	if query.outcome != "" && !slices.Contains(runOutcomes, query.outcome) {
+		return query, errHistoryQuery.New("outcome", "It must be succeeded or failed.")
+	}
+
+	for name, value := range map[string]*int{"offset": &query.offset, "limit": &query.limit} {
+		if raw := c.Query(name); raw != "" {
+			*value, err = strconv.Atoi(raw)
+			if err != nil || *value < 0 {
+				return query, errHistoryQuery.New(name, "It must be a non-negative integer.")
+			}
+		}
+	}
+	if query.limit < 1 || query.limit > maxHistoryLimit {
+		return query, errHistoryQuery.New("limit", fmt.Sprintf("It must be from 1 to %d.", maxHistoryLimit))
+	}
+
+	return query, nil
+}
+
+// matches reports whether the run started within the time range and has the outcome
+func (q historyQuery) matches(run *syncRun) bool {
+	if !q.from.IsZero() && run.StartedAt.Before(q.from) {
+		return false
+	}
+	if !q.to.IsZero() && !run.StartedAt.Before(q.to) {
+		return false
+	}
+	return q.outcome == "" || run.Outcome == q.outcome
+}
+
+// Return the page of the runs matching the query, the newest first
+func queryHistory(query historyQuery) syncHistoryPage {
+	syncHistory.Lock()
+	defer syncHistory.Unlock()
+
+	page := syncHistoryPage{Offset: query.offset, Limit: query.limit, Runs: make([]syncRun, 0)}
+	for i := len(syncHistory.runs) - 1; i >= 0; i-- {
+		if !query.matches(&syncHistory.runs[i]) {
+			continue
+		}
+		if page.Total >= query.offset && len(page.Runs) < query.limit {
+			page.Runs = append(page.Runs, syncHistory.runs[i])
+		}
+		page.Total++
+	}
+	return page
+}
+
+// GetSyncHistory returns the runs of the syncs, the newest first.
+//
+// Query parameters:
+//   - from, to: RFC 3339 times, the runs started from (inclusive) and until (exclusive) them.
+//   - outcome:  succeeded or failed.
+//   - offset, limit: the page, 50 runs from the newest by default, at most 1000.
+//
+// Response Codes:
+//   - 200 OK: Returned with the page of the runs and the number of runs matching the filters.
+//   - 400 Bad Request: Returned when a query parameter is not valid.
+func GetSyncHistory(c *gin.Context) {
+	query, err := parseHistoryQuery(c)
+	if err != nil {
+		logFor(c.Request.Context()).Error(err.Error())
+		writeProblem(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, queryHistory(query))
+}
@@ -0,0 +1,210 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Empty the run history and restore its settings after the test
+func resetSyncHistory(t *testing.T) {
+	reset := func() {
+		syncHistory.Lock()
+		syncHistory.file, syncHistory.maxRuns, syncHistory.runs, syncHistory.fileLines = "", 0, nil, 0
+		syncHistory.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// A run started at the minute of the base time
+func historyRun(id string, minute int, outcome string) syncRun {
+	start := time.Date(2026, 10, 1, 12, minute, 0, 0, time.UTC)
+	return syncRun{ID: id, StartedAt: start, EndedAt: start.Add(time.Second), Trigger: triggerApi, TriggeredBy: "alice", Outcome: outcome}
+}
+
+func runIds(runs []syncRun) string {
+	ids := make([]string, 0, len(runs))
+	for _, run := range runs {
+		ids = append(ids, run.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func Test_validHistoryConfig(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	tests := []struct {
+		name        string
+		settings    yamlHistoryConfig
+		wantMaxRuns int
+		wantErr     error
+	}{
+		{"Normal case: Defaults", yamlHistoryConfig{}, defaultMaxRuns, nil},
+		{"Normal case: max_runs set", yamlHistoryConfig{File: "history.jsonl", MaxRuns: intPtr(10)}, 10, nil},
+		{"Error case: max_runs zero", yamlHistoryConfig{MaxRuns: intPtr(0)}, 0, errOutOfRange},
+		{"Error case: max_runs over the limit", yamlHistoryConfig{MaxRuns: intPtr(maxRunsLimit + 1)}, 0, errOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validHistoryConfig(&tt.settings)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validHistoryConfig() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && *tt.settings.MaxRuns != tt.wantMaxRuns {
+				t.Errorf("validHistoryConfig() max_runs = %d, want %d", *tt.settings.MaxRuns, tt.wantMaxRuns)
+			}
+		})
+	}
+}
+
+func Test_GetSyncHistory(t *testing.T) {
+	resetSyncHistory(t)
+	for i, outcome := range []string{runSucceeded, runFailed, runSucceeded, runSucceeded, runFailed} {
+		recordSyncRun(historyRun(string(rune('a'+i)), i*10, outcome))
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTotal  int
+		wantIds    string
+	}{
+		{"Normal case: Newest first", "", http.StatusOK, 5, "e,d,c,b,a"},
+		{"Normal case: Outcome", "?outcome=failed", http.StatusOK, 2, "e,b"},
+		{"Normal case: Time range", "?from=2026-10-01T12:10:00Z&to=2026-10-01T12:30:00Z", http.StatusOK, 2, "c,b"},
+		{"Normal case: Time range with an offset", "?from=2026-10-01T21:10:00%2B09:00", http.StatusOK, 4, "e,d,c,b"},
+		{"Normal case: Page", "?offset=1&limit=2", http.StatusOK, 5, "d,c"},
+		{"Normal case: Page past the end", "?offset=10", http.StatusOK, 5, ""},
+		{"Error case: from not a time", "?from=yesterday", http.StatusBadRequest, 0, ""},
+		{"Error case: Unknown outcome", "?outcome=partial", http.StatusBadRequest, 0, ""},
+		{"Error case: Negative offset", "?offset=-1", http.StatusBadRequest, 0, ""},
+		{"Error case: limit zero", "?limit=0", http.StatusBadRequest, 0, ""},
+		{"Error case: limit over the maximum", "?limit=1001", http.StatusBadRequest, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ginContext, _ := gin.CreateTestContext(w)
+			ginContext.Request = httptest.NewRequest(http.MethodGet, "/cdim/api/v1/devices/sync/history"+tt.query, nil)
+
+			GetSyncHistory(ginContext)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GetSyncHistory() status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				if !strings.Contains(w.Body.String(), `"0035"`) {
+					t.Errorf("GetSyncHistory() body = %s, want code 0035", w.Body.String())
+				}
+				return
+			}
+			var page syncHistoryPage
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("GetSyncHistory() body = %s, %v", w.Body.String(), err)
+			}
+			if page.Total != tt.wantTotal || runIds(page.Runs) != tt.wantIds {
+				t.Errorf("GetSyncHistory() total = %d, runs = %q, want %d, %q", page.Total, runIds(page.Runs), tt.wantTotal, tt.wantIds)
+			}
+		})
+	}
+}
+
+func Test_recordSyncRun_maxRuns(t *testing.T) {
+	resetSyncHistory(t)
+	applyHistoryConfig(&yamlHistoryConfig{MaxRuns: &[]int{3}[0]})
+
+	for i := range 5 {
+		recordSyncRun(historyRun(string(rune('a'+i)), i, runSucceeded))
+	}
+
+	if got := runIds(queryHistory(historyQuery{limit: maxHistoryLimit}).Runs); got != "e,d,c" {
+		t.Errorf("recordSyncRun() runs = %q, want %q", got, "e,d,c")
+	}
+}
+
+func Test_syncHistory_file(t *testing.T) {
+	resetSyncHistory(t)
+	path := filepath.Join(t.TempDir(), "data", "history.jsonl")
+	settings := yamlHistoryConfig{File: path, MaxRuns: &[]int{2}[0]}
+	applyHistoryConfig(&settings)
+
+	for i := range 5 {
+		recordSyncRun(historyRun(string(rune('a'+i)), i, runSucceeded))
+	}
+
+	// The file is compacted to the runs kept once it has twice as many lines
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 2 {
+		t.Errorf("history file lines = %d, want 2", lines)
+	}
+
+	// A restart reads the runs back, skipping lines that cannot be read
+	if err := os.WriteFile(path, append(content, []byte("not json\n")...), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	resetSyncHistory(t)
+	applyHistoryConfig(&settings)
+	if got := runIds(queryHistory(historyQuery{limit: maxHistoryLimit}).Runs); got != "e,d" {
+		t.Errorf("history after reload = %q, want %q", got, "e,d")
+	}
+}
+
+func Test_syncRun_outcome(t *testing.T) {
+	ginContext, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginContext.Request = httptest.NewRequest(http.MethodPost, "/cdim/api/v1/devices/sync", nil)
+	ginContext.Set(requestIdKey, "req-1")
+	run := newSyncRun(ginContext, time.Now())
+
+	result := syncResult{CollectedDevices: 4, ForwardedDevices: 3, AbnormalDevices: 1, IncompleteDevices: 2, QuarantinedDevices: 1}
+	tests := []struct {
+		name        string
+		got         syncRun
+		wantOutcome string
+		wantPhase   string
+		wantCode    string
+	}{
+		{"Normal case: Forward succeeded", run.completed(result, deliveryOutcome{StatusCode: 200, Succeeded: true}), runSucceeded, "", ""},
+		{"Error case: Forward failed", run.completed(result, deliveryOutcome{StatusCode: 503, Error: "unavailable"}), runFailed, "forward", ""},
+		{"Error case: Collection failed", run.failed("collect", errCollectTarget.New()), runFailed, "collect", errCollectTarget.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.ID != "req-1" || tt.got.Trigger != triggerApi || tt.got.TriggeredBy == "" {
+				t.Errorf("syncRun = %+v, want the request ID, trigger and caller", tt.got)
+			}
+			if tt.got.Outcome != tt.wantOutcome || tt.got.FailedPhase != tt.wantPhase || tt.got.ErrorCode != tt.wantCode {
+				t.Errorf("syncRun outcome = %s, phase = %s, code = %s, want %s, %s, %s",
+					tt.got.Outcome, tt.got.FailedPhase, tt.got.ErrorCode, tt.wantOutcome, tt.wantPhase, tt.wantCode)
+			}
+			if tt.got.EndedAt.Before(tt.got.StartedAt) {
+				t.Errorf("syncRun ended at %v, before it started at %v", tt.got.EndedAt, tt.got.StartedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Self-monitoring alert names. They are notified as the syncFailure kind.
+const (
+	syncCollectFailure      string = "syncCollectFailure"
+	syncForwardFailure      string = "syncForwardFailure"
+	syncConsecutiveFailures string = "syncConsecutiveFailures"
+	syncStalled             string = "syncStalled"
+)
+
+var monitoringAlerts = []string{syncCollectFailure, syncForwardFailure, syncConsecutiveFailures, syncStalled}
+
+const (
+	defaultConsecutiveFailures int = 3
+	maxConsecutiveFailures     int = 1000
+	defaultStalledMinutes      int = 0
+	maxStalledMinutes          int = 525600
+)
+
+// Interval at which the time since the last successful sync is checked
+var stalledCheckInterval = time.Minute
+
+// A sync fails when the settings cannot be loaded, the collection fails or the forward fails.
+// It succeeds when the forward succeeds.
+//
+//   - consecutive_failures: syncConsecutiveFailures is notified when that many syncs in a row have failed, 0 disables it.
+//   - stalled_minutes:      syncStalled is notified when no sync has succeeded for that many minutes, 0 disables it.
+type yamlMonitoringConfig struct {
+	ConsecutiveFailures *int `yaml:"consecutive_failures"`
+	StalledMinutes      *int `yaml:"stalled_minutes"`
+}
+
+// Health of the syncs, shared by all of them
+var syncHealth = struct {
+	sync.Mutex
+	consecutiveFailures int
+	// Time of the last successful sync, or of the start of the exporter
+	lastSuccess     time.Time
+	stalledNotified bool
+	// Settings of the latest sync that loaded them, used to notify when they cannot be loaded anymore
+	lastSettings *yamlContent
+}{lastSuccess: time.Now()}
+
+// Check the monitoring_configs settings
+func validMonitoringConfig(settings *yamlMonitoringConfig) error {
+	var err error
+	settings.ConsecutiveFailures, err = validConfigCount("monitoring_configs/consecutive_failures", settings.ConsecutiveFailures, defaultConsecutiveFailures, maxConsecutiveFailures)
+	if err != nil {
+		return err
+	}
+	settings.StalledMinutes, err = validConfigCount("monitoring_configs/stalled_minutes", settings.StalledMinutes, defaultStalledMinutes, maxStalledMinutes)
+	return err
+}
+
+// kind returns the notification kind of the alert, the self-monitoring alerts are of kind syncFailure
+func (a plannedAlert) kind() string {
+	for _, name := range monitoringAlerts {
+		if a.name == name {
+			return syncFailure
+		}
+	}
+	return a.name
+}
+
+// Keep the settings, so that the failure to load them later can be notified
+func rememberSettings(settings *yamlContent) {
+	syncHealth.Lock()
+	defer syncHealth.Unlock()
+	syncHealth.lastSettings = settings
+}
+
+// Return the settings of the latest sync that loaded them, or nil
+func rememberedSettings() *yamlContent {
+	syncHealth.Lock()
+	defer syncHealth.Unlock()
+	return syncHealth.lastSettings
+}
+
+// recordSyncSuccess resets the failure count
+func recordSyncSuccess() {
+	syncHealth.Lock()
+	defer syncHealth.Unlock()
+
+	syncHealth.consecutiveFailures = 0
+	syncHealth.lastSuccess = time.Now()
+	syncHealth.stalledNotified = false
+}
+
+// recordSyncFailure counts a failed sync and notifies it as the alert name.
+// syncConsecutiveFailures is notified as well when the count reaches monitoring_configs/consecutive_failures.
+func recordSyncFailure(ctx context.Context, settings *yamlContent, alertName string, phase string, err error) []deliveryOutcome {
+	syncHealth.Lock()
+	syncHealth.consecutiveFailures++
+	failures := syncHealth.consecutiveFailures
+	lastSuccess := syncHealth.lastSuccess
+	syncHealth.Unlock()
+
+	entry := gin.H{"phase": phase, "message": err.Error(), "consecutiveFailures": failures}
+	var expErr *ExpError
+	if errors.As(err, &expErr) {
+		entry["code"] = expErr.Code
+		entry["message"] = expErr.Message
+	}
+	alerts := []plannedAlert{{alertName, []any{entry}}}
+
+	threshold := *settings.MonitoringConfigs.ConsecutiveFailures
+	if threshold > 0 && failures == threshold {
+		logFor(ctx).Error(fmt.Sprintf("%d syncs in a row have failed.", failures))
+		alerts = append(alerts, plannedAlert{syncConsecutiveFailures, []any{gin.H{
+			"consecutiveFailures": failures,
+			"lastSuccess":         lastSuccess.UTC().Format(time.RFC3339),
+		}}})
+	}
+
+	outcomes := make([]deliveryOutcome, 0)
+	for _, alert := range alerts {
+		outcomes = append(outcomes, notifyAll(ctx, settings, alert)...)
+	}
+	return outcomes
+}
+
+// checkStalled notifies syncStalled once when no sync has succeeded for monitoring_configs/stalled_minutes
+func checkStalled(ctx context.Context, settings *yamlContent, now time.Time) {
+	stalledMinutes := *settings.MonitoringConfigs.StalledMinutes
+	if stalledMinutes == 0 {
+		return
+	}
+
+	syncHealth.Lock()
+	lastSuccess := syncHealth.lastSuccess
+	stalled := !syncHealth.stalledNotified && now.Sub(lastSuccess) >= time.Duration(stalledMinutes)*time.Minute
+	if stalled {
+		syncHealth.stalledNotified = true
+	}
+	syncHealth.Unlock()
+
+	if !stalled {
+		return
+	}
+
+	logFor(ctx).Error(fmt.Sprintf("No sync has succeeded for %d minutes.", stalledMinutes))
+	notifyAll(ctx, settings, plannedAlert{syncStalled, []any{gin.H{
+		"stalledMinutes": stalledMinutes,
+		"lastSuccess":    lastSuccess.UTC().Format(time.RFC3339),
+	}}})
+}
+
+// StartMonitoring checks in the background that syncs keep succeeding, until Shutdown.
+// The settings are loaded at every check, those of the latest sync are used when they cannot be loaded.
+// The logging and audit settings loaded are applied as well, so that their changes apply without a sync.
+func StartMonitoring() {
+	inflight.Add(1)
+	go func() {
+		defer inflight.Done()
+
+		ticker := time.NewTicker(stalledCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-serverCtx.Done():
+				return
+			case now := <-ticker.C:
+				settings := &yamlContent{}
+				if err := loadConfig(yamlFilePath, settings); err != nil {
+					settings = rememberedSettings()
+				} else {
+					applyLoggingConfig(&settings.LoggingConfigs)
+					applyAuditConfig(&settings.AuditConfigs)
+					applyHistoryConfig(&settings.HistoryConfigs)
+				}
+				if settings != nil {
+					checkStalled(serverCtx, settings, now)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Start a sink recording the names of the alerts it receives, and return settings notifying it
+func newMonitoringSettings(t *testing.T, consecutiveFailures, stalledMinutes int) (*yamlContent, func() []string) {
+	var mu sync.Mutex
+	names := make([]string, 0)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alerts alertContentList
+		json.NewDecoder(r.Body).Decode(&alerts)
+		mu.Lock()
+		names = append(names, alerts[0].Labels.Alertname)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(testServer.Close)
+
+	settings := &yamlContent{
+		AlertConfigs:      yamlAlertConfig{TargetUrl: testServer.URL, TimeOut: new(int)},
+		MonitoringConfigs: yamlMonitoringConfig{ConsecutiveFailures: &consecutiveFailures, StalledMinutes: &stalledMinutes},
+	}
+	if err := validNotificationConfig(&settings.NotificationConfigs, &settings.AlertConfigs); err != nil {
+		t.Fatalf("validNotificationConfig() error = %v", err)
+	}
+
+	return settings, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(names)
+	}
+}
+
+func Test_recordSyncFailure(t *testing.T) {
+	settings, received := newMonitoringSettings(t, 2, 0)
+	recordSyncSuccess()
+
+	recordSyncFailure(context.Background(), settings, syncCollectFailure, "collect", errors.New("refused"))
+	outcomes := recordSyncFailure(context.Background(), settings, syncForwardFailure, "forward", errors.New("status code = 500"))
+	recordSyncFailure(context.Background(), settings, syncForwardFailure, "forward", errors.New("status code = 500"))
+
+	want := []string{syncCollectFailure, syncForwardFailure, syncConsecutiveFailures, syncForwardFailure}
+	if got := received(); !slices.Equal(got, want) {
+		t.Errorf("recordSyncFailure() notified %v, want %v", got, want)
+	}
+	if len(outcomes) != 2 || !outcomes[0].Succeeded || outcomes[1].AlertName != syncConsecutiveFailures {
+		t.Errorf("recordSyncFailure() outcomes = %+v", outcomes)
+	}
+
+	// The count starts over after a success
+	recordSyncSuccess()
+	recordSyncFailure(context.Background(), settings, syncCollectFailure, "collect", errors.New("refused"))
+	if got := received(); len(got) != 5 {
+		t.Errorf("recordSyncFailure() after a success notified %v", got)
+	}
+}
+
+func Test_checkStalled(t *testing.T) {
+	settings, received := newMonitoringSettings(t, 0, 10)
+	recordSyncSuccess()
+	now := time.Now()
+
+	checkStalled(context.Background(), settings, now.Add(9*time.Minute))
+	if got := received(); len(got) != 0 {
+		t.Errorf("checkStalled() before the limit notified %v", got)
+	}
+
+	checkStalled(context.Background(), settings, now.Add(10*time.Minute))
+	checkStalled(context.Background(), settings, now.Add(20*time.Minute))
+	if got := received(); !slices.Equal(got, []string{syncStalled}) {
+		t.Errorf("checkStalled() notified %v, want once %s", got, syncStalled)
+	}
+
+	// A success rearms the check
+	recordSyncSuccess()
+	checkStalled(context.Background(), settings, time.Now().Add(10*time.Minute))
+	if got := received(); len(got) != 2 {
+		t.Errorf("checkStalled() after a success notified %v", got)
+	}
+}
+
+func Test_plannedAlert_kind(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{abnormalStatusDeviceList, abnormalStatusDeviceList},
+		{syncForwardFailure, syncFailure},
+		{syncStalled, syncFailure},
+	}
+	for _, tt := range tests {
+		t.Run("Normal case: "+tt.name, func(t *testing.T) {
+			if got := (plannedAlert{name: tt.name}).kind(); got != tt.want {
+				t.Errorf("kind() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// syncResult summarizes a sync. It is logged and returned to the caller.
+type syncResult struct {
+	// Devices in the hw-control response
+	CollectedDevices int `json:"collectedDevices"`
+	// Devices that did not conform to the device schema
+	QuarantinedDevices int `json:"quarantinedDevices"`
+	// Devices and entries of incompleteDeviceList removed by filter_configs
+	FilteredDevices int `json:"filteredDevices"`
+	// Devices left out of a targeted sync
+	UntargetedDevices int `json:"untargetedDevices,omitempty"`
+	// Whether the sync was targeted, its forward is then flagged as partial
+	Partial bool `json:"partial,omitempty"`
+	// Devices forwarded to configuration-manager
+	ForwardedDevices int `json:"forwardedDevices"`
+	// Forwarded devices with an abnormal status
+	AbnormalDevices int `json:"abnormalDevices"`
+	// Alerts on devices suppressed by a maintenance window, in all the alerts
+	SuppressedDevices int `json:"suppressedDevices"`
+	// Entries of incompleteDeviceList
+	IncompleteDevices int `json:"incompleteDevices"`
+	// Suppressed devices with the alert, the window and its reason
+	Suppressed []suppressedDevice `json:"suppressed,omitempty"`
+
+	// The following are set only when the caller waits for the sync to finish
+
+	// Outcome of the forward to configuration-manager
+	Forward *deliveryOutcome `json:"forward,omitempty"`
+	// Outcomes of the alerts, in the order they were queued
+	Alerts []deliveryOutcome `json:"alerts,omitempty"`
+	// Duration of the whole sync in milliseconds
+	DurationMs int64 `json:"durationMs,omitempty"`
+}
+
+// deliveryOutcome is the outcome of a forward or an alert
+type deliveryOutcome struct {
+	// Name of the alert, empty for the forward
+	AlertName string `json:"alertName,omitempty"`
+	// Name of the notification sink, empty for the forward
+	Sink string `json:"sink,omitempty"`
+	// Status code of the response, 0 when no response was received
+	StatusCode int    `json:"statusCode,omitempty"`
+	Succeeded  bool   `json:"succeeded"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+
+	start time.Time
+}
+
+// Start measuring a delivery
+func startDelivery(alertName string) deliveryOutcome {
+	return deliveryOutcome{AlertName: alertName, start: time.Now()}
+}
+
+// Return the outcome of a delivery that received no response
+func (o deliveryOutcome) failed(err error) deliveryOutcome {
+	o.Error = err.Error()
+	o.DurationMs = time.Since(o.start).Milliseconds()
+	return o
+}
+
+// Return the outcome of a delivery that received a response
+func (o deliveryOutcome) completed(statusCode int, succeeded bool) deliveryOutcome {
+	o.StatusCode = statusCode
+	o.Succeeded = succeeded
+	if !succeeded {
+		o.Error = fmt.Sprintf("status code = %d", statusCode)
+	}
+	o.DurationMs = time.Since(o.start).Milliseconds()
+	return o
+}
+
+// succeeded reports whether the forward and every alert of a waited sync succeeded
+func (r *syncResult) succeeded() bool {
+	if r.Forward == nil || !r.Forward.Succeeded {
+		return false
+	}
+	for _, alert := range r.Alerts {
+		if !alert.Succeeded {
+			return false
+		}
+	}
+	return true
+}
+
+// Log the summary of the sync as an event.
+// It is sync.planned when the forward and the alerts have just started, sync.completed with their outcomes when they have finished.
+func (r *syncResult) log(ctx context.Context) {
+	logFor(ctx).event(r.event())
+}
+
+func (r *syncResult) event() logEvent {
+	event := logEvent{
+		Event:     "sync.planned",
+		Phase:     "sync",
+		Succeeded: true,
+		Devices: map[string]int{
+			"collected":   r.CollectedDevices,
+			"quarantined": r.QuarantinedDevices,
+			"filtered":    r.FilteredDevices,
+			"untargeted":  r.UntargetedDevices,
+			"forwarded":   r.ForwardedDevices,
+			"abnormal":    r.AbnormalDevices,
+			"suppressed":  r.SuppressedDevices,
+			"incomplete":  r.IncompleteDevices,
+		},
+	}
+	if r.Forward == nil {
+		return event
+	}
+
+	event.Event = "sync.completed"
+	event.Succeeded = r.succeeded()
+	event.StatusCode = r.Forward.StatusCode
+	event.DurationMs = r.DurationMs
+	event.Alerts = len(r.Alerts)
+	for _, alert := range r.Alerts {
+		if !alert.Succeeded {
+			event.FailedAlerts++
+		}
+	}
+	return event
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_syncResult_succeeded(t *testing.T) {
+	ok := startDelivery("").completed(201, true)
+	ng := startDelivery(abnormalStatusDeviceList).failed(errors.New("connection refused"))
+
+	tests := []struct {
+		name   string
+		result syncResult
+		want   bool
+	}{
+		{"Normal case: Forward and alerts succeeded", syncResult{Forward: &ok, Alerts: []deliveryOutcome{ok}}, true},
+		{"Normal case: Forward succeeded without alerts", syncResult{Forward: &ok}, true},
+		{"Error case: Forward failed", syncResult{Forward: &ng}, false},
+		{"Error case: An alert failed", syncResult{Forward: &ok, Alerts: []deliveryOutcome{ok, ng}}, false},
+		{"Error case: Not waited", syncResult{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.succeeded(); got != tt.want {
+				t.Errorf("succeeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_deliveryOutcome_completed(t *testing.T) {
+	got := startDelivery("").completed(500, false)
+	if got.Succeeded || got.StatusCode != 500 || got.Error != "status code = 500" {
+		t.Errorf("completed() = %+v", got)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Query parameter flagging the forward of a targeted sync,
+// so that configuration-manager does not treat the devices missing from it as removed
+const partialQueryParam string = "partial"
+
+// syncTarget is the optional body of a sync request restricting it to some devices.
+// A device is synchronized when its ID is listed or it matches one of the selectors.
+type syncTarget struct {
+	DeviceIDs []string             `json:"deviceIDs"`
+	Selectors []yamlDeviceSelector `json:"selectors"`
+
+	// Compiled selectors, set by parseSyncTarget
+	selectors []*deviceSelector
+}
+
+// parseSyncTarget reads the target from the request body.
+// It returns nil when the body is empty, that is when every device is synchronized.
+func parseSyncTarget(c *gin.Context, labels map[string][]string) (*syncTarget, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, errSyncTarget.Wrap(err, "Failed to read it.")
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	target := &syncTarget{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target); err != nil {
+		return nil, errSyncTarget.Wrap(err, err)
+	}
+	if len(target.DeviceIDs) == 0 && len(target.Selectors) == 0 {
+		return nil, errSyncTarget.New("At least one of deviceIDs and selectors is required.")
+	}
+
+	target.selectors, err = compileDeviceSelectors("selectors", target.Selectors, labels)
+	if err != nil {
+		return nil, errSyncTarget.Wrap(err, asExpError(err).Message)
+	}
+
+	return target, nil
+}
+
+// matches reports whether the device is listed or matches one of the selectors
+func (t *syncTarget) matches(device *Device) bool {
+	return slices.Contains(t.DeviceIDs, device.ID) || matchAny(t.selectors, device) >= 0
+}
+
+// selectTargetDevices keeps the targeted devices and returns them with the number of the other ones.
+// Every device is kept when there is no target.
+func selectTargetDevices(ctx context.Context, target *syncTarget, devices []Device) ([]Device, int) {
+	if target == nil {
+		return devices, 0
+	}
+
+	kept := make([]Device, 0, len(devices))
+	for i := range devices {
+		if target.matches(&devices[i]) {
+			kept = append(kept, devices[i])
+		}
+	}
+
+	logFor(ctx).Info(fmt.Sprintf("targeted sync: %d devices out of %d are synchronized.", len(kept), len(devices)))
+	return kept, len(devices) - len(kept)
+}
+
+// collectUrl returns the collect URL of the target.
+// When hw-control accepts device IDs (collect_configs/device_id_query) and the target lists IDs only,
+// they are passed as query parameters so that hw-control returns those devices only.
+// Otherwise the devices are collected as usual and selected afterwards.
+func (t *syncTarget) collectUrl(settings *yamlCollectConfig) string {
+	if t == nil || settings.DeviceIdQuery == "" || len(t.Selectors) > 0 {
+		return settings.TargetUrl
+	}
+
+	values := make(url.Values)
+	values[settings.DeviceIdQuery] = t.DeviceIDs
+	return withQuery(settings.TargetUrl, values)
+}
+
+// partialForwardConfig returns the forward settings flagging the forward as partial
+func partialForwardConfig(settings yamlForwardConfig) yamlForwardConfig {
+	settings.TargetUrl = withQuery(settings.TargetUrl, url.Values{partialQueryParam: {"true"}})
+	return settings
+}
+
+// Add the values to the query of the URL, which has been validated by loadConfig
+func withQuery(rawUrl string, values url.Values) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+
+	query := u.Query()
+	for key, list := range values {
+		query[key] = append(query[key], list...)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
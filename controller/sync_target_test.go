@@ -0,0 +1,122 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_parseSyncTarget(t *testing.T) {
+	labels := map[string][]string{"lab": {"lab1"}}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantTarget bool
+		wantErr    bool
+	}{
+		{"Normal case: Empty body synchronizes every device", "", false, false},
+		{"Normal case: Device IDs", `{"deviceIDs": ["dev1"]}`, true, false},
+		{"Normal case: Selectors", `{"selectors": [{"labels": ["lab"]}]}`, true, false},
+		{"Error case: Neither device IDs nor selectors", `{}`, false, true},
+		{"Error case: Unknown field", `{"ids": ["dev1"]}`, false, true},
+		{"Error case: Not JSON", `dev1`, false, true},
+		{"Error case: Undefined label", `{"selectors": [{"labels": ["none"]}]}`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ginContext, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ginContext.Request = httptest.NewRequest(http.MethodPost, "/cdim/api/v1/devices/sync", strings.NewReader(tt.body))
+
+			target, err := parseSyncTarget(ginContext, labels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSyncTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && GetStatusCode(err) != http.StatusBadRequest {
+				t.Errorf("parseSyncTarget() status = %d, want %d", GetStatusCode(err), http.StatusBadRequest)
+			}
+			if (target != nil) != tt.wantTarget {
+				t.Errorf("parseSyncTarget() = %v, wantTarget %v", target, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func Test_syncTarget_collectUrl(t *testing.T) {
+	settings := yamlCollectConfig{TargetUrl: "http://hw-control/devices?detail=true", DeviceIdQuery: "deviceID"}
+
+	tests := []struct {
+		name     string
+		target   *syncTarget
+		settings yamlCollectConfig
+		want     string
+	}{
+		{"Normal case: No target", nil, settings, settings.TargetUrl},
+		{"Normal case: Device IDs are passed", &syncTarget{DeviceIDs: []string{"dev1", "dev2"}}, settings, "http://hw-control/devices?detail=true&deviceID=dev1&deviceID=dev2"},
+		{"Normal case: Selectors are not passed", &syncTarget{DeviceIDs: []string{"dev1"}, Selectors: []yamlDeviceSelector{{Types: []string{"CPU"}}}}, settings, settings.TargetUrl},
+		{"Normal case: hw-control does not accept device IDs", &syncTarget{DeviceIDs: []string{"dev1"}}, yamlCollectConfig{TargetUrl: settings.TargetUrl}, settings.TargetUrl},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.collectUrl(&tt.settings); got != tt.want {
+				t.Errorf("collectUrl() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_planSync_target(t *testing.T) {
+	var query string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"deviceList": [
+			{"deviceID": "dev1", "type": "CPU", "status": {"state": "Enabled", "health": "OK"}},
+			{"deviceID": "dev2", "type": "memory", "status": {"state": "Enabled", "health": "OK"}},
+			{"deviceID": "dev3", "type": "memory", "status": {"state": "Enabled", "health": "OK"}}
+		], "infoTimestamp": "2025-01-01T00:00:00Z"}`))
+	}))
+	defer testServer.Close()
+
+	settings := yamlContent{}
+	if err := loadConfig("testdata/exporter.yaml", &settings); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	settings.CollectConfigs.TargetUrl = testServer.URL
+	settings.CollectConfigs.DeviceIdQuery = "deviceID"
+
+	target := &syncTarget{DeviceIDs: []string{"dev1"}, Selectors: []yamlDeviceSelector{{DeviceIDs: []string{"dev3"}}}}
+	target.selectors, _ = compileDeviceSelectors("selectors", target.Selectors, nil)
+
+	plan, err := planSync(context.Background(), &settings, target)
+	if err != nil {
+		t.Fatalf("planSync() error = %v", err)
+	}
+	if query != "" {
+		t.Errorf("planSync() collected with query %q, want none with selectors", query)
+	}
+	if !plan.result.Partial || plan.result.ForwardedDevices != 2 || plan.result.UntargetedDevices != 1 {
+		t.Errorf("planSync() result = %+v", plan.result)
+	}
+	if !strings.HasSuffix(plan.forward.TargetUrl, "?partial=true") {
+		t.Errorf("planSync() forward url = %s, want it flagged as partial", plan.forward.TargetUrl)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultTracingServiceName string  = "configuration-exporter"
+	defaultTracingSampleRatio float64 = 1
+)
+
+// Spans are exported with OTLP over HTTP. The settings are read when the exporter starts.
+//
+//   - enabled:      whether spans are exported, the trace context is propagated in any case.
+//   - endpoint:     the OTLP/HTTP endpoint, such as http://otel-collector:4318.
+//     When omitted, OTEL_EXPORTER_OTLP_ENDPOINT or its default (http://localhost:4318) is used.
+//   - service_name: the service.name resource attribute.
+//   - sample_ratio: the ratio of the traces started by the exporter that are sampled, from 0 to 1.
+//     The decision of the caller is followed for the traces it started.
+type yamlTracingConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Endpoint    string   `yaml:"endpoint"`
+	ServiceName string   `yaml:"service_name"`
+	SampleRatio *float64 `yaml:"sample_ratio"`
+}
+
+// The tracer of the sync pipeline. It does nothing until StartTracing installs a provider.
+var tracer = otel.Tracer("github.com/project-cdim/configuration-exporter/controller")
+
+// Check the tracing_configs settings and fill in the defaults
+func validTracingConfig(settings *yamlTracingConfig) error {
+	if settings.Endpoint != "" {
+		err := validConfigUrl("tracing_configs/endpoint", settings.Endpoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	if settings.ServiceName == "" {
+		settings.ServiceName = defaultTracingServiceName
+	}
+
+	if settings.SampleRatio == nil {
+		sampleRatio := defaultTracingSampleRatio
+		settings.SampleRatio = &sampleRatio
+	}
+	if *settings.SampleRatio < 0 || *settings.SampleRatio > 1 {
+		return errOutOfRange.New("tracing_configs/sample_ratio")
+	}
+
+	return nil
+}
+
+// StartTracing sets up the W3C trace context propagation and, when tracing_configs/enabled is set,
+// the export of the spans. It returns the function flushing the spans not exported yet, to be called on shutdown.
+// The exporter runs without exporting when the settings cannot be loaded.
+func StartTracing(ctx context.Context) func(context.Context) error {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	settings := yamlContent{}
+	if err := loadConfig(yamlFilePath, &settings); err != nil {
+		log.Error(fmt.Sprintf("Spans are not exported. %s", err.Error()))
+		return func(context.Context) error { return nil }
+	}
+	if !settings.TracingConfigs.Enabled {
+		return func(context.Context) error { return nil }
+	}
+
+	provider, err := newTracerProvider(ctx, &settings.TracingConfigs)
+	if err != nil {
+		log.Error(fmt.Sprintf("Spans are not exported. %s", err.Error()))
+		return func(context.Context) error { return nil }
+	}
+	otel.SetTracerProvider(provider)
+
+	log.Info(fmt.Sprintf("Spans are exported as %s.", settings.TracingConfigs.ServiceName))
+	return provider.Shutdown
+}
+
+// newTracerProvider builds a provider exporting the spans in batches with OTLP over HTTP
+func newTracerProvider(ctx context.Context, settings *yamlTracingConfig) (*sdktrace.TracerProvider, error) {
+	options := make([]otlptracehttp.Option, 0)
+	if settings.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(settings.Endpoint))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", settings.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(*settings.SampleRatio))),
+	), nil
+}
+
+// TracingMiddleware starts a server span for every request, continuing the trace of the caller
+// given by the traceparent header. The span is named after the route and carries the request ID.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("request.id", RequestIdOf(c)),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// startClientSpan starts the span of an outbound request to the target URL
+func startClientSpan(ctx context.Context, name string, method string, targetUrl string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", method), attribute.String("url.full", redactUrl(targetUrl))),
+		trace.WithAttributes(attributes...),
+	)
+}
+
+// injectTraceContext sets the traceparent header of the outbound request from the span of ctx
+func injectTraceContext(ctx context.Context, req *http.Request) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+// endDeliverySpan records the outcome of a forward or an alert and ends the span
+func endDeliverySpan(span trace.Span, outcome deliveryOutcome) {
+	if outcome.StatusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", outcome.StatusCode))
+	}
+	if !outcome.Succeeded {
+		span.SetStatus(codes.Error, outcome.Error)
+	}
+	span.End()
+}
+
+// Return the URL without its password, or as is when it cannot be parsed
+func redactUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	return u.Redacted()
+}
+
+// endSpan records the error, if any, and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,214 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var spanRecorder = tracetest.NewSpanRecorder()
+var installSpanRecorder sync.Once
+
+// Record the spans of the tests. The tracer delegates to the first provider installed, so it is installed once.
+func recordSpans() {
+	installSpanRecorder.Do(func() {
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+}
+
+// Return the ended span of the trace with the name
+func endedSpan(traceId trace.TraceID, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spanRecorder.Ended() {
+		if span.SpanContext().TraceID() == traceId && span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func Test_validTracingConfig(t *testing.T) {
+	ratio := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name     string
+		settings yamlTracingConfig
+		wantErr  bool
+	}{
+		{"Normal case: Defaults", yamlTracingConfig{}, false},
+		{"Normal case: Endpoint and sample ratio", yamlTracingConfig{Enabled: true, Endpoint: "http://otel-collector:4318", SampleRatio: ratio(0.5)}, false},
+		{"Error case: Endpoint is not a URL", yamlTracingConfig{Endpoint: "otel-collector"}, true},
+		{"Error case: Sample ratio is out of range", yamlTracingConfig{SampleRatio: ratio(1.5)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validTracingConfig(&tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validTracingConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (tt.settings.ServiceName == "" || tt.settings.SampleRatio == nil) {
+				t.Errorf("validTracingConfig() did not fill in the defaults: %+v", tt.settings)
+			}
+		})
+	}
+}
+
+func Test_tracing_outboundRequests(t *testing.T) {
+	recordSpans()
+
+	var traceparents []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		mu.Unlock()
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"deviceList": [{"deviceID": "dev1"}], "infoTimestamp": "2025-01-01T00:00:00Z"}`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx, root := tracer.Start(context.Background(), "sync")
+	traceId := root.SpanContext().TraceID()
+
+	timeout := 600
+	settings := yamlContent{CollectConfigs: yamlCollectConfig{TargetUrl: server.URL, TimeOut: &timeout}}
+	if err := requestDevices(ctx, &settings, &Output{}); err != nil {
+		t.Fatalf("requestDevices() error = %v", err)
+	}
+	forwardData(ctx, server.Client(), &yamlForwardConfig{TargetUrl: server.URL, TimeOut: &timeout}, []any{"dev1"})
+	root.End()
+
+	for _, traceparent := range traceparents {
+		if !strings.Contains(traceparent, traceId.String()) {
+			t.Errorf("traceparent = %s, want the trace %s", traceparent, traceId)
+		}
+	}
+	if len(traceparents) != 2 {
+		t.Errorf("%d requests were received, want 2", len(traceparents))
+	}
+
+	tests := []struct {
+		name       string
+		spanName   string
+		wantStatus int
+		wantError  bool
+	}{
+		{"Normal case: Collect span", "collect", http.StatusOK, false},
+		{"Error case: Forward span of a failed forward", "forward", http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span := endedSpan(traceId, tt.spanName)
+			if span == nil {
+				t.Fatalf("span %s was not recorded", tt.spanName)
+			}
+			if span.SpanKind() != trace.SpanKindClient || span.Parent().SpanID() != root.SpanContext().SpanID() {
+				t.Errorf("span %s kind = %v, parent = %v", tt.spanName, span.SpanKind(), span.Parent().SpanID())
+			}
+			if !containsAttribute(span.Attributes(), attribute.Int("http.response.status_code", tt.wantStatus)) {
+				t.Errorf("span %s attributes = %v, want status %d", tt.spanName, span.Attributes(), tt.wantStatus)
+			}
+			if (span.Status().Code == codes.Error) != tt.wantError {
+				t.Errorf("span %s status = %v, wantError %v", tt.spanName, span.Status(), tt.wantError)
+			}
+		})
+	}
+}
+
+func Test_TracingMiddleware(t *testing.T) {
+	recordSpans()
+
+	callerTraceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+	router := gin.New()
+	router.Use(RequestIdMiddleware(), TracingMiddleware())
+	router.POST("/cdim/api/v1/devices/sync", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/cdim/api/v1/devices/sync", nil)
+	req.Header.Set("traceparent", "00-"+callerTraceId+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	traceId, _ := trace.TraceIDFromHex(callerTraceId)
+	span := endedSpan(traceId, "POST /cdim/api/v1/devices/sync")
+	if span == nil {
+		t.Fatal("TracingMiddleware() did not continue the trace of the caller")
+	}
+	if span.SpanKind() != trace.SpanKindServer || !containsAttribute(span.Attributes(), attribute.Int("http.response.status_code", http.StatusAccepted)) {
+		t.Errorf("TracingMiddleware() span kind = %v, attributes = %v", span.SpanKind(), span.Attributes())
+	}
+}
+
+func Test_newTracerProvider(t *testing.T) {
+	// A stand-in for the OpenTelemetry collector
+	received := make(chan *http.Request, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case received <- r:
+		default:
+		}
+	}))
+	defer collector.Close()
+
+	settings := yamlTracingConfig{Enabled: true, Endpoint: collector.URL}
+	if err := validTracingConfig(&settings); err != nil {
+		t.Fatalf("validTracingConfig() error = %v", err)
+	}
+	provider, err := newTracerProvider(context.Background(), &settings)
+	if err != nil {
+		t.Fatalf("newTracerProvider() error = %v", err)
+	}
+
+	_, span := provider.Tracer("test").Start(context.Background(), "sync")
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	select {
+	case r := <-received:
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("collector received %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+	default:
+		t.Error("collector did not receive the spans")
+	}
+}
+
+func containsAttribute(attributes []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, a := range attributes {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
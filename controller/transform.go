@@ -0,0 +1,264 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Operations of the transform rules
+const (
+	transformRename  string = "rename"
+	transformDrop    string = "drop"
+	transformDefault string = "default"
+	transformCompute string = "compute"
+	transformMap     string = "map"
+)
+
+// The transform rules are applied in order to every forwarded device.
+// Fields are given as dotted paths (e.g. status.state), intermediate objects are created as needed.
+//
+//   - rename:  moves field to to.
+//   - drop:    removes field.
+//   - default: sets field to value when it is missing or null.
+//   - compute: sets field to the result of the Go template, executed with the device as data.
+//   - map:     sets field to the value selected by the JSONPath path, or to the list of values when path has a wildcard.
+type yamlTransformConfig struct {
+	Rules []yamlTransformRule `yaml:"rules"`
+
+	// Compiled rules, set by validTransformConfig
+	steps []transformStep
+}
+
+type yamlTransformRule struct {
+	Op       string `yaml:"op"`
+	Field    string `yaml:"field"`
+	To       string `yaml:"to"`
+	Value    any    `yaml:"value"`
+	Template string `yaml:"template"`
+	Path     string `yaml:"path"`
+}
+
+// transformStep is a compiled transform rule
+type transformStep struct {
+	rule  string
+	apply func(device map[string]any) error
+}
+
+// Check the transform_configs settings and compile the rules
+func validTransformConfig(settings *yamlTransformConfig) error {
+	settings.steps = make([]transformStep, 0, len(settings.Rules))
+
+	for i, rule := range settings.Rules {
+		targetName := fmt.Sprintf("transform_configs/rules[%d]", i)
+
+		step, err := compileTransformRule(rule)
+		if err != nil {
+			return errTransformRule.Wrap(err, targetName, err)
+		}
+		step.rule = targetName
+		settings.steps = append(settings.steps, step)
+	}
+
+	return nil
+}
+
+// Compile a transform rule into a step
+func compileTransformRule(rule yamlTransformRule) (transformStep, error) {
+	field := splitFieldPath(rule.Field)
+	if field == nil {
+		return transformStep{}, fmt.Errorf("field is required")
+	}
+
+	switch rule.Op {
+	case transformRename:
+		to := splitFieldPath(rule.To)
+		if to == nil {
+			return transformStep{}, fmt.Errorf("to is required")
+		}
+		return transformStep{apply: func(device map[string]any) error {
+			if value, ok := removeField(device, field); ok {
+				setField(device, to, value)
+			}
+			return nil
+		}}, nil
+
+	case transformDrop:
+		return transformStep{apply: func(device map[string]any) error {
+			removeField(device, field)
+			return nil
+		}}, nil
+
+	case transformDefault:
+		if rule.Value == nil {
+			return transformStep{}, fmt.Errorf("value is required")
+		}
+		value := convertYamlValue(rule.Value)
+		return transformStep{apply: func(device map[string]any) error {
+			if current, ok := getField(device, field); !ok || current == nil {
+				setField(device, field, deepCopyValue(value))
+			}
+			return nil
+		}}, nil
+
+	case transformCompute:
+		tmpl, err := template.New(rule.Field).Parse(rule.Template)
+		if err != nil {
+			return transformStep{}, err
+		}
+		return transformStep{apply: func(device map[string]any) error {
+			var sb strings.Builder
+			if err := tmpl.Execute(&sb, device); err != nil {
+				return err
+			}
+			setField(device, field, sb.String())
+			return nil
+		}}, nil
+
+	case transformMap:
+		path, err := compileJsonPath(rule.Path)
+		if err != nil {
+			return transformStep{}, err
+		}
+		return transformStep{apply: func(device map[string]any) error {
+			if !path.definite() {
+				setField(device, field, deepCopyValue(path.find(device)))
+				return nil
+			}
+			if value, ok := path.lookup(device); ok {
+				setField(device, field, deepCopyValue(value))
+			}
+			return nil
+		}}, nil
+	}
+
+	return transformStep{}, fmt.Errorf("op %q is not supported", rule.Op)
+}
+
+// transformDevice applies the steps to a copy of the device, the device itself is left unchanged.
+// A step that fails is skipped and the following steps are still applied.
+func transformDevice(ctx context.Context, steps []transformStep, device Device) any {
+	if len(steps) == 0 {
+		return device
+	}
+
+	transformed, _ := deepCopyValue(device.Raw).(map[string]any)
+	if transformed == nil {
+		return device
+	}
+
+	for _, step := range steps {
+		if err := step.apply(transformed); err != nil {
+			logFor(ctx).Warn(fmt.Sprintf("%s was not applied to device %s. %s", step.rule, device.ID, err))
+		}
+	}
+
+	return transformed
+}
+
+// Split a dotted field path, or return nil when it is empty
+func splitFieldPath(field string) []string {
+	if field == "" {
+		return nil
+	}
+	return strings.Split(field, ".")
+}
+
+// Return the value at the field path
+func getField(object map[string]any, field []string) (any, bool) {
+	current := object
+	for _, name := range field[:len(field)-1] {
+		next, ok := current[name].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	value, ok := current[field[len(field)-1]]
+	return value, ok
+}
+
+// Set the value at the field path, replacing non-object intermediate values with objects
+func setField(object map[string]any, field []string, value any) {
+	current := object
+	for _, name := range field[:len(field)-1] {
+		next, ok := current[name].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			current[name] = next
+		}
+		current = next
+	}
+	current[field[len(field)-1]] = value
+}
+
+// Remove the value at the field path and return it
+func removeField(object map[string]any, field []string) (any, bool) {
+	value, ok := getField(object, field)
+	if !ok {
+		return nil, false
+	}
+
+	parent := object
+	if len(field) > 1 {
+		parentValue, _ := getField(object, field[:len(field)-1])
+		parent = parentValue.(map[string]any)
+	}
+	delete(parent, field[len(field)-1])
+
+	return value, true
+}
+
+// Copy a decoded JSON value so that changes to the copy do not affect the original
+func deepCopyValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(typed))
+		for k, v := range typed {
+			copied[k] = deepCopyValue(v)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(typed))
+		for i, v := range typed {
+			copied[i] = deepCopyValue(v)
+		}
+		return copied
+	}
+	return value
+}
+
+// Convert a value decoded by yaml.v2 into one that can be encoded as JSON,
+// since yaml.v2 decodes mappings as map[interface{}]interface{}
+func convertYamlValue(value any) any {
+	switch typed := value.(type) {
+	case map[any]any:
+		converted := make(map[string]any, len(typed))
+		for k, v := range typed {
+			converted[fmt.Sprint(k)] = convertYamlValue(v)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(typed))
+		for i, v := range typed {
+			converted[i] = convertYamlValue(v)
+		}
+		return converted
+	}
+	return value
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2025 NEC Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func Test_validTransformConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    yamlTransformRule
+		wantErr bool
+	}{
+		{"Normal case: rename", yamlTransformRule{Op: "rename", Field: "a", To: "b"}, false},
+		{"Error case: rename without to", yamlTransformRule{Op: "rename", Field: "a"}, true},
+		{"Normal case: drop", yamlTransformRule{Op: "drop", Field: "a"}, false},
+		{"Error case: default without value", yamlTransformRule{Op: "default", Field: "a"}, true},
+		{"Error case: compute with an invalid template", yamlTransformRule{Op: "compute", Field: "a", Template: "{{.type"}, true},
+		{"Error case: map with an invalid path", yamlTransformRule{Op: "map", Field: "a", Path: "links"}, true},
+		{"Error case: Rule without field", yamlTransformRule{Op: "drop"}, true},
+		{"Error case: Unsupported op", yamlTransformRule{Op: "merge", Field: "a"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := yamlTransformConfig{Rules: []yamlTransformRule{tt.rule}}
+			err := validTransformConfig(&settings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validTransformConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_transformDevice(t *testing.T) {
+	raw := map[string]any{
+		"deviceID":    "dev1",
+		"type":        "CPU",
+		"constraints": map[string]any{"x": 1.0},
+		"status":      map[string]any{"state": "Enabled", "health": "OK"},
+		"links":       []any{map[string]any{"type": "memory", "deviceID": "dev2"}},
+	}
+
+	settings := yamlTransformConfig{Rules: []yamlTransformRule{
+		{Op: "rename", Field: "status.state", To: "state"},
+		{Op: "drop", Field: "constraints"},
+		{Op: "default", Field: "attribute.vendor", Value: map[any]any{"name": "unknown"}},
+		{Op: "default", Field: "type", Value: "other"},
+		{Op: "compute", Field: "displayName", Template: "{{.type}}-{{.deviceID}}"},
+		{Op: "map", Field: "linkedDeviceIDs", Path: "$.links[*].deviceID"},
+		{Op: "map", Field: "health", Path: "$.status.health"},
+		{Op: "map", Field: "missing", Path: "$.status.unknown"},
+	}}
+	if err := validTransformConfig(&settings); err != nil {
+		t.Fatalf("validTransformConfig() error = %v", err)
+	}
+
+	got := transformDevice(context.Background(), settings.steps, newDevice(raw))
+
+	want := map[string]any{
+		"deviceID":        "dev1",
+		"type":            "CPU",
+		"state":           "Enabled",
+		"status":          map[string]any{"health": "OK"},
+		"links":           []any{map[string]any{"type": "memory", "deviceID": "dev2"}},
+		"attribute":       map[string]any{"vendor": map[string]any{"name": "unknown"}},
+		"displayName":     "CPU-dev1",
+		"linkedDeviceIDs": []any{"dev2"},
+		"health":          "OK",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transformDevice() = %v, want %v", got, want)
+	}
+
+	// The collected device is left unchanged
+	if _, ok := raw["constraints"]; !ok {
+		t.Error("transformDevice() changed the original device")
+	}
+}
+
+func Test_transformDevice_noRules(t *testing.T) {
+	device := newDevice(map[string]any{"deviceID": "dev1"})
+	if got := transformDevice(context.Background(), nil, device); !reflect.DeepEqual(got, device) {
+		t.Errorf("transformDevice() = %v, want the device unchanged", got)
+	}
+}
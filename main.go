@@ -15,6 +15,15 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/project-cdim/configuration-exporter/controller"
 
 	logger "github.com/project-cdim/cdim-go-logger"
@@ -27,13 +36,35 @@ import (
 // v1 route base url
 const URL_BASE_V1 = "/cdim/api/v1"
 
+// Grace period for in-flight requests and background syncs on shutdown
+const shutdownTimeout = 30 * time.Second
+
 // Audit Trail Logger
 var log, _ = logger.New(logger_common.Option{Tag: logger_common.TAG_TRAIL})
 
 func main() {
+	dryRun := flag.Bool("dry-run", false, "run one sync without forwarding nor alerting and print the payloads that would have been sent")
+	flag.Parse()
+
+	if *dryRun {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		if err := controller.DryRun(ctx, os.Stdout); err != nil {
+			log.Error(err.Error())
+			stop()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Apply the log levels and the caller identification of the settings
+	controller.StartLogging()
+
 	// Create an instance of gin Engine
 	router := gin.Default()
-	// Add custom middleware to gin Engine for logging
+	// Add custom middleware to gin Engine for the request ID, then for tracing and logging
+	router.Use(controller.RequestIdMiddleware())
+	router.Use(controller.TracingMiddleware())
 	router.Use(logMiddleware())
 
 	router.Use(cors.New(cors.Config{
@@ -44,25 +75,105 @@ func main() {
 			"*",
 		},
 		// Allowed HTTP request headers
-		AllowHeaders: []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
+		AllowHeaders: []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Request-ID", "traceparent", "tracestate", "If-None-Match", "Last-Event-ID"},
+		// Response headers readable by the browser
+		ExposeHeaders: []string{"X-Request-ID", "ETag"},
 	}))
 
 	// v1 route group
 	v1 := router.Group(URL_BASE_V1)
 	// API to get devices data and to forward that data
 	v1.POST("/devices/sync", controller.SyncDevices)
+	// API to get the runs of the syncs, filtered by time range and outcome
+	v1.GET("/devices/sync/history", controller.GetSyncHistory)
+	// API to get the devices excluded from the latest sync because they do not conform to the device schema
+	v1.GET("/devices/quarantine", controller.GetQuarantinedDevices)
+	// APIs to get the devices classified abnormal or incomplete by the latest full sync, with the reason
+	v1.GET("/devices/abnormal", controller.GetAbnormalDevices)
+	v1.GET("/devices/incomplete", controller.GetIncompleteDevices)
+	// API to stream the changes of the devices between full syncs as Server-Sent Events
+	v1.GET("/devices/events", controller.GetDeviceEvents)
+	// APIs to read the devices collected by the latest full sync, without querying hw-control
+	v1.GET("/devices", controller.GetDevices)
+	v1.GET("/devices/:id", controller.GetDevice)
+	// API to get why a device of the latest full sync was classified normal or abnormal
+	v1.GET("/devices/:id/status-evaluation", controller.GetStatusEvaluation)
+	// APIs to manage the maintenance windows, during which matching devices are not alerted on
+	v1.GET("/maintenance-windows", controller.GetMaintenanceWindows)
+	v1.POST("/maintenance-windows", controller.CreateMaintenanceWindow)
+	v1.GET("/maintenance-windows/:id", controller.GetMaintenanceWindow)
+	v1.DELETE("/maintenance-windows/:id", controller.DeleteMaintenanceWindow)
+	// APIs to get and change the log levels at runtime
+	v1.GET("/admin/loglevel", controller.GetLogLevel)
+	v1.PUT("/admin/loglevel", controller.SetLogLevel)
+
+	// Notify when no sync has succeeded for a while
+	controller.StartMonitoring()
+
+	// Export the spans of the syncs when tracing_configs/enabled is set
+	shutdownTracing := controller.StartTracing(context.Background())
 
-	router.Run(":8080") // listen and serve on 0.0.0.0:8080 (for windows "localhost:8080")
+	// listen and serve on 0.0.0.0:8080 (for windows "localhost:8080")
+	srv := &http.Server{Addr: ":8080", Handler: router}
+	// End the device event streams and answer the callers waiting for a sync, Shutdown waits for the requests in progress
+	srv.RegisterOnShutdown(controller.CloseEventStreams)
+	srv.RegisterOnShutdown(controller.BeginShutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(err.Error())
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+
+	// Stop accepting requests, then cancel the syncs still running in the background.
+	// Each step has its own grace period, so that a slow one does not leave none to the next.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error(err.Error())
+	}
+	syncsCtx, cancelSyncs := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelSyncs()
+	if err := controller.Shutdown(syncsCtx); err != nil {
+		log.Error(err.Error())
+	}
+	// Export the spans of the syncs that have just finished
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelTracing()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		log.Error(err.Error())
+	}
 }
 
 // custom middleware for gin
 // * Process Description
 //   - Before API execution : logging the start of API
 //   - After API execution  : logging the end of API
+//
+// The start records the caller: its user, where the user comes from, its IP address and its user agent.
+// Both records carry the request ID, which ties them to the application log lines of the request.
+// Above the info trail level, the start is logged after the API execution, only when the end is logged.
 func logMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		log.TrailReq(ctx.Request.Method, ctx.Request.URL.Path, "-", "request start.")
+		requestId := controller.RequestIdOf(ctx)
+		caller := controller.CallerOf(ctx)
+		started := controller.TrailRecordsRequests()
+		if started {
+			log.TrailReq(ctx.Request.Method, ctx.Request.URL.Path, caller.User, "request start. requestId = "+requestId+", "+caller.String())
+		}
 		ctx.Next()
-		log.TrailRes(ctx.Writer.Status(), "response end.")
+		if !controller.TrailRecordsResponse(ctx.Writer.Status()) {
+			return
+		}
+		if !started {
+			log.TrailReq(ctx.Request.Method, ctx.Request.URL.Path, caller.User, "request start. requestId = "+requestId+", "+caller.String())
+		}
+		log.TrailRes(ctx.Writer.Status(), "response end. requestId = "+requestId)
 	}
 }